@@ -47,13 +47,17 @@ function App() {
     }
   }
 
-  const resolveIncident = async (incidentId) => {
+  const resolveIncident = async (incident) => {
     try {
-      const res = await fetch(`${API_BASE}/api/incidents/${incidentId}`, {
+      const res = await fetch(`${API_BASE}/api/incidents/${incident.id}`, {
         method: 'PATCH',
-        headers: { 'Content-Type': 'application/json' },
+        headers: { 'Content-Type': 'application/json', 'If-Match': `"${incident.version}"` },
         body: JSON.stringify({ status: 'resolved' })
       })
+      if (res.status === 409) {
+        fetchIncidents()
+        throw new Error('Incident was changed by someone else; reloaded')
+      }
       if (!res.ok) throw new Error('Failed to resolve incident')
       fetchIncidents()
     } catch (err) {
@@ -158,7 +162,7 @@ function App() {
                       OPEN
                     </span>
                     <button 
-                      onClick={() => resolveIncident(incident.id)}
+                      onClick={() => resolveIncident(incident)}
                       style={{ 
                         fontSize: '11px', 
                         padding: '4px 8px', 
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const (
+	replayMaxRange = 7 * 24 * time.Hour
+	replayMaxTicks = 10000
+)
+
+// ReplayDetection dry-runs burst detection over a historical range and
+// returns the incidents it would have created.
+func (h *Handler) ReplayDetection(c echo.Context) error {
+	loc, err := requestTZ(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	since, err := parseSince(c.QueryParam("since"), 24*time.Hour, loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	until, err := parseUntil(c.QueryParam("until"), loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if !until.After(since) {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "until must be after since"})
+	}
+	if until.Sub(since) > replayMaxRange {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("replay range may not exceed %s", replayMaxRange)})
+	}
+
+	step := h.detectionInterval
+	if step <= 0 {
+		step = time.Minute
+	}
+	if ticks := until.Sub(since) / step; ticks > replayMaxTicks {
+		step = until.Sub(since) / replayMaxTicks
+	}
+
+	incidents, err := h.detector.Replay(c.Request().Context(), since, until, step)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to replay detection"})
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"since":     since,
+		"until":     until,
+		"step":      step.String(),
+		"incidents": incidents,
+	})
+}
+
+const previewMaxWindow = 24 * time.Hour
+
+// DetectionPreview shows what burst detection sees for ?service= right now,
+// over its configured window or ?window=, without creating anything.
+func (h *Handler) DetectionPreview(c echo.Context) error {
+	service := c.QueryParam("service")
+	if service == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "service is required"})
+	}
+	var window time.Duration
+	if raw := c.QueryParam("window"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 || d > previewMaxWindow {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("invalid window %q: use a positive duration up to %s", raw, previewMaxWindow)})
+		}
+		window = d
+	}
+
+	preview, err := h.detector.Preview(c.Request().Context(), service, window, time.Now().UTC())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to preview detection"})
+	}
+	return c.JSON(http.StatusOK, preview)
+}
+
+// RunMaintenance vacuums and analyzes the logs table, and incidents too with
+// ?incidents=true. It runs synchronously for up to the configured
+// maintenance timeout and answers 409 if a run is already in progress.
+func (h *Handler) RunMaintenance(c echo.Context) error {
+	tables := []string{"logs"}
+	if ok, _ := strconv.ParseBool(c.QueryParam("incidents")); ok {
+		tables = append(tables, "incidents")
+	}
+
+	// The route is exempt from the request timeout; bound it here instead
+	// and keep the connection writable for as long as the run may take.
+	ctx, cancel := context.WithTimeout(c.Request().Context(), h.maintenanceTimeout)
+	defer cancel()
+	http.NewResponseController(c.Response()).SetWriteDeadline(time.Now().Add(h.maintenanceTimeout + 5*time.Second))
+
+	start := time.Now()
+	err := h.repo.VacuumAnalyze(ctx, tables)
+	duration := time.Since(start)
+	switch {
+	case errors.Is(err, store.ErrBusy):
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return c.JSON(http.StatusGatewayTimeout, echo.Map{"error": fmt.Sprintf("maintenance did not finish within %s", h.maintenanceTimeout)})
+	case err != nil:
+		log.Printf("maintenance: %v", err)
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "maintenance failed"})
+	}
+	log.Printf("maintenance: vacuumed %v in %s", tables, duration.Round(time.Millisecond))
+	return c.JSON(http.StatusOK, echo.Map{
+		"tables":      tables,
+		"duration_ms": duration.Milliseconds(),
+	})
+}
+
+const detectLogsMax = 10000
+
+// DetectFromLogsRequest names the logs to run detection over: either
+// explicit log_ids, or a filter (service, since, until, metadata) with an
+// optional limit. Metadata filters only on keys in INDEXED_METADATA_KEYS.
+type DetectFromLogsRequest struct {
+	LogIDs   []int64           `json:"log_ids"`
+	Service  string            `json:"service"`
+	Since    *time.Time        `json:"since"`
+	Until    *time.Time        `json:"until"`
+	Metadata map[string]string `json:"metadata"`
+	Limit    int               `json:"limit"`
+}
+
+// DetectFromLogs dry-runs burst detection over just the selected logs and
+// returns, per service, what it would conclude.
+func (h *Handler) DetectFromLogs(c echo.Context) error {
+	var req DetectFromLogsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	filtered := req.Service != "" || req.Since != nil || req.Until != nil || len(req.Metadata) > 0
+	if len(req.LogIDs) > 0 == filtered {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "provide either log_ids or a filter (service, since, until, metadata)"})
+	}
+
+	ctx := c.Request().Context()
+	var logs []store.LogEntry
+	var err error
+	if len(req.LogIDs) > 0 {
+		if len(req.LogIDs) > detectLogsMax {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("at most %d log_ids may be given", detectLogsMax)})
+		}
+		logs, err = h.repo.GetLogsByIDs(ctx, req.LogIDs)
+	} else {
+		if req.Since != nil && req.Until != nil && !req.Until.After(*req.Since) {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "until must be after since"})
+		}
+		for key := range req.Metadata {
+			if !h.metadataKeys[key] {
+				return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("metadata key %q is not indexed", key)})
+			}
+		}
+		limit := req.Limit
+		if limit <= 0 || limit > detectLogsMax {
+			limit = detectLogsMax
+		}
+		logs, err = h.repo.ListLogs(ctx, store.LogQuery{
+			Service:  req.Service,
+			Since:    req.Since,
+			Until:    req.Until,
+			Metadata: req.Metadata,
+			Limit:    limit,
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load logs"})
+	}
+
+	verdicts, err := h.detector.EvaluateLogs(ctx, logs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to evaluate detection"})
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"logs":     len(logs),
+		"services": verdicts,
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// alertmanagerMaxAlerts bounds how many unresolved incidents are exported.
+const alertmanagerMaxAlerts = 1000
+
+// alertmanagerAlert is an alert as Alertmanager's v2 API accepts it on
+// POST /api/v2/alerts.
+type alertmanagerAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+}
+
+// AlertmanagerIncidents returns the unresolved incidents as Alertmanager
+// alerts, ready to be pushed to /api/v2/alerts for routing. Snoozed
+// incidents are left out, as in the on-call queue. Alerts carry no endsAt,
+// so once an incident is resolved and stops appearing here Alertmanager
+// resolves it after its resolve_timeout.
+func (h *Handler) AlertmanagerIncidents(c echo.Context) error {
+	incidents, err := h.repo.ListUnresolvedIncidents(c.Request().Context(), alertmanagerMaxAlerts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list incidents"})
+	}
+
+	now := time.Now()
+	base := c.Scheme() + "://" + c.Request().Host
+	alerts := make([]alertmanagerAlert, 0, len(incidents))
+	for _, inc := range incidents {
+		if inc.SnoozedUntil != nil && inc.SnoozedUntil.After(now) {
+			continue
+		}
+		labels := map[string]string{
+			"alertname":   "Incident",
+			"incident_id": strconv.FormatInt(inc.ID, 10),
+			"severity":    inc.Severity,
+			"status":      inc.Status,
+		}
+		if inc.Service != nil {
+			labels["service"] = *inc.Service
+		}
+		if len(inc.Tags) > 0 {
+			labels["tags"] = strings.Join(inc.Tags, ",")
+		}
+
+		annotations := map[string]string{
+			"summary":     inc.Description,
+			"description": inc.Description,
+		}
+		if inc.Summary != nil && *inc.Summary != "" {
+			annotations["summary"] = *inc.Summary
+		}
+		if inc.RootCause != nil && *inc.RootCause != "" {
+			annotations["root_cause"] = *inc.RootCause
+		}
+
+		alerts = append(alerts, alertmanagerAlert{
+			Labels:       labels,
+			Annotations:  annotations,
+			StartsAt:     inc.CreatedAt.UTC(),
+			GeneratorURL: fmt.Sprintf("%s/api/incidents/%d", base, inc.ID),
+		})
+	}
+	return c.JSON(http.StatusOK, alerts)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/auth"
+)
+
+// parseAPIKeys parses API_KEYS, a comma-separated list of name:key pairs.
+func parseAPIKeys(raw string) map[string]string {
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		name, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || name == "" || key == "" {
+			continue
+		}
+		keys[key] = name
+	}
+	return keys
+}
+
+// authMiddleware resolves the caller's principal and stores it in the request
+// context. With API keys configured, every route except the health check must
+// present one via "Authorization: Bearer <key>" or X-API-Key, and the key's
+// name becomes the principal. Ingestion requests carrying a service token are
+// left to ingestTokenMiddleware instead. Without keys, the optional X-User
+// header is trusted as the principal.
+func authMiddleware(apiKeys map[string]string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if len(apiKeys) == 0 {
+				if user := strings.TrimSpace(req.Header.Get("X-User")); user != "" {
+					c.SetRequest(req.WithContext(auth.WithPrincipal(req.Context(), user)))
+				}
+				return next(c)
+			}
+
+			if c.Path() == "/api/health" {
+				return next(c)
+			}
+			if isIngestRoute(c) && req.Header.Get(ingestTokenHeader) != "" {
+				return next(c)
+			}
+
+			presented := req.Header.Get("X-API-Key")
+			if bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+				presented = strings.TrimSpace(bearer)
+			}
+			name, ok := lookupAPIKey(apiKeys, presented)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, echo.Map{"error": "missing or invalid API key"})
+			}
+			c.SetRequest(req.WithContext(auth.WithPrincipal(req.Context(), name)))
+			return next(c)
+		}
+	}
+}
+
+func lookupAPIKey(apiKeys map[string]string, presented string) (string, bool) {
+	if presented == "" {
+		return "", false
+	}
+	for key, name := range apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(presented)) == 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/auth"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const bulkTagMaxIDs = 500
+
+type BulkTagRequest struct {
+	IDs  []int64  `json:"ids" validate:"required,min=1,dive,gt=0"`
+	Tags []string `json:"tags"`
+	Mode string   `json:"mode" validate:"required,oneof=add replace remove"`
+}
+
+// BulkTagIncidents adds, replaces or removes tags on many incidents in one
+// transaction. Each ID gets its own result; a missing incident or one that
+// would end up with too many tags is skipped without failing the others.
+func (h *Handler) BulkTagIncidents(c echo.Context) error {
+	var req BulkTagRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if len(tags) == 0 && req.Mode != "replace" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "tags must not be empty"})
+	}
+	ids := slices.Compact(slices.Sorted(slices.Values(req.IDs)))
+	if len(ids) > bulkTagMaxIDs {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("at most %d ids are allowed", bulkTagMaxIDs)})
+	}
+
+	retag := func(current []string) ([]string, error) {
+		switch req.Mode {
+		case "add":
+			return normalizeTags(append(current, tags...))
+		case "remove":
+			return slices.DeleteFunc(current, func(t string) bool { return slices.Contains(tags, t) }), nil
+		default:
+			return tags, nil
+		}
+	}
+	ctx := c.Request().Context()
+	results, err := h.repo.RetagIncidents(ctx, ids, auth.Actor(ctx), retag)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to tag incidents"})
+	}
+
+	updated := 0
+	for _, r := range results {
+		if r.Status == store.RetagUpdated {
+			updated++
+		}
+	}
+	return c.JSON(http.StatusOK, echo.Map{"mode": req.Mode, "tags": tags, "updated": updated, "results": results})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// defaultCacheMaxAges covers the routes whose answers change slowly enough
+// for clients to reuse them without asking. Everything else is no-cache and
+// revalidated by ETag.
+var defaultCacheMaxAges = map[string]time.Duration{
+	"/api/meta/services": time.Minute,
+	"/api/meta/levels":   time.Minute,
+}
+
+// cacheControlMiddleware lets successful GET responses on the routes in
+// defaultCacheMaxAges, as overridden by maxAges, be cached for their
+// max-age. When API keys guard the API the responses are private, so shared
+// caches never hand them to someone without a key.
+func cacheControlMiddleware(maxAges map[string]time.Duration, private bool) echo.MiddlewareFunc {
+	merged := make(map[string]time.Duration, len(defaultCacheMaxAges)+len(maxAges))
+	for route, d := range defaultCacheMaxAges {
+		merged[route] = d
+	}
+	for route, d := range maxAges {
+		merged[route] = d
+	}
+	scope := "public"
+	if private {
+		scope = "private"
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			method := c.Request().Method
+			d := merged[c.Path()]
+			if d <= 0 || (method != http.MethodGet && method != http.MethodHead) {
+				return next(c)
+			}
+			value := fmt.Sprintf("%s, max-age=%d", scope, int(d.Seconds()))
+			res := c.Response()
+			res.Before(func() {
+				if res.Status == http.StatusOK || res.Status == http.StatusNotModified {
+					res.Header().Set(echo.HeaderCacheControl, value)
+				}
+			})
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/rootcause"
+)
+
+const candidateLogLimit = 2000
+
+// IncidentCandidates suggests a root cause from the error logs across all
+// services from shortly before the incident opened until it was resolved
+// (or now). Unlike /api/summary it needs no ML service.
+func (h *Handler) IncidentCandidates(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	ctx := c.Request().Context()
+	incident, err := h.repo.GetIncident(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+
+	since := incident.CreatedAt.Add(-postmortemLogLead)
+	until := time.Now().UTC()
+	if incident.ResolvedAt != nil {
+		until = *incident.ResolvedAt
+	}
+	logs, err := h.repo.ListErrorLogs(ctx, since, until, candidateLogLimit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load incident logs"})
+	}
+
+	var service string
+	if incident.Service != nil {
+		service = *incident.Service
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"incident_id": id,
+		"since":       since,
+		"until":       until,
+		"truncated":   len(logs) == candidateLogLimit,
+		"analysis":    rootcause.Analyze(service, logs),
+	})
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	correlationLead       = 15 * time.Minute
+	correlationMaxRange   = 7 * 24 * time.Hour
+	correlationBuckets    = 60
+	correlationMinBucket  = 10 * time.Second
+	correlationMaxBuckets = 2000
+)
+
+// serviceCorrelation is how closely one service's errors tracked the
+// reference service's over an incident window.
+type serviceCorrelation struct {
+	Service string `json:"service"`
+	// Correlation is the Pearson coefficient of the two services' error
+	// counts per bucket, nil when either series is flat.
+	Correlation *float64 `json:"correlation"`
+	Errors      int64    `json:"errors"`
+	// CoBuckets counts the buckets where both services logged errors.
+	CoBuckets int       `json:"co_buckets"`
+	PeakAt    time.Time `json:"peak_at"`
+}
+
+// IncidentCorrelation ranks the services whose errors rose and fell with
+// the incident's service over the incident window: from ?lead= (default
+// 15m) before it was opened until it was resolved, or now. ?since= and
+// ?until= pick another window, ?service= another reference service, and
+// ?interval= the bucket width (default a sixtieth of the window).
+func (h *Handler) IncidentCorrelation(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+	ctx := c.Request().Context()
+	incident, err := h.repo.GetIncident(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+
+	reference := c.QueryParam("service")
+	if reference == "" && incident.Service != nil {
+		reference = *incident.Service
+	}
+	if reference == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "incident has no service; pass ?service= to correlate against"})
+	}
+
+	loc, err := requestTZ(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	lead := correlationLead
+	if raw := c.QueryParam("lead"); raw != "" {
+		if lead, err = time.ParseDuration(raw); err != nil || lead < 0 {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("invalid lead %q: use a duration like 15m", raw)})
+		}
+	}
+	since := incident.CreatedAt.Add(-lead)
+	if raw := c.QueryParam("since"); raw != "" {
+		if since, err = parseSince(raw, 0, loc); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+		}
+	}
+	until := time.Now().UTC()
+	if incident.ResolvedAt != nil {
+		until = *incident.ResolvedAt
+	}
+	if raw := c.QueryParam("until"); raw != "" {
+		if until, err = parseUntil(raw, loc); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+		}
+	}
+	if !until.After(since) {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "until must be after since"})
+	}
+	if until.Sub(since) > correlationMaxRange {
+		// A long-open incident: look at the most recent stretch.
+		since = until.Add(-correlationMaxRange)
+	}
+
+	interval := max((until.Sub(since) / correlationBuckets).Round(time.Second), correlationMinBucket)
+	if raw := c.QueryParam("interval"); raw != "" {
+		if interval, err = time.ParseDuration(raw); err != nil || interval < time.Second {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("invalid interval %q: use a duration of at least 1s", raw)})
+		}
+	}
+	if buckets := until.Sub(since) / interval; buckets > correlationMaxBuckets {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("range spans %d intervals; widen interval or narrow the range (max %d)", buckets, correlationMaxBuckets)})
+	}
+	limit, err := parseLimit(c.QueryParam("limit"), 10, 100)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	series, err := h.repo.ErrorSeriesByService(ctx, interval, since, until)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to compute error series"})
+	}
+	ref := series[reference]
+	if ref == nil {
+		ref = make([]int64, int((until.Sub(since)+interval-1)/interval))
+	}
+
+	services := []serviceCorrelation{}
+	for service, counts := range series {
+		if service == reference {
+			continue
+		}
+		sc := serviceCorrelation{Service: service, Correlation: pearson(ref, counts)}
+		peak := 0
+		for i, n := range counts {
+			sc.Errors += n
+			if n > 0 && ref[i] > 0 {
+				sc.CoBuckets++
+			}
+			if n > counts[peak] {
+				peak = i
+			}
+		}
+		sc.PeakAt = since.Add(time.Duration(peak) * interval)
+		services = append(services, sc)
+	}
+	slices.SortFunc(services, func(a, b serviceCorrelation) int {
+		return cmp.Or(
+			cmp.Compare(correlationOrZero(b.Correlation), correlationOrZero(a.Correlation)),
+			cmp.Compare(b.CoBuckets, a.CoBuckets),
+			cmp.Compare(b.Errors, a.Errors),
+			cmp.Compare(a.Service, b.Service),
+		)
+	})
+	if len(services) > limit {
+		services = services[:limit]
+	}
+
+	var refErrors int64
+	for _, n := range ref {
+		refErrors += n
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"incident_id": incident.ID,
+		"service":     reference,
+		"errors":      refErrors,
+		"since":       since,
+		"until":       until,
+		"interval":    interval.String(),
+		"services":    services,
+	})
+}
+
+// pearson returns the correlation coefficient of two equally long series,
+// or nil when either has no variance.
+func pearson(x, y []int64) *float64 {
+	n := float64(len(x))
+	if n == 0 {
+		return nil
+	}
+	var sx, sy float64
+	for i := range x {
+		sx += float64(x[i])
+		sy += float64(y[i])
+	}
+	mx, my := sx/n, sy/n
+	var cov, vx, vy float64
+	for i := range x {
+		dx, dy := float64(x[i])-mx, float64(y[i])-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return nil
+	}
+	r := math.Round(cov/math.Sqrt(vx*vy)*1000) / 1000
+	return &r
+}
+
+func correlationOrZero(r *float64) float64 {
+	if r == nil {
+		return 0
+	}
+	return *r
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const (
+	reprocessMaxBatches = 500
+	// reprocessMaxRejected is how many rejected logs a reprocess response
+	// lists.
+	reprocessMaxRejected = 50
+)
+
+// rejectedLog is a dead-lettered log the database refused on replay.
+type rejectedLog struct {
+	FailedIngestionID int64          `json:"failed_ingestion_id"`
+	Log               store.LogEntry `json:"log"`
+	Error             string         `json:"error"`
+}
+
+// INGEST_DB_FAILURE_MODE values: what ingestion does when the database is
+// unreachable.
+const (
+	ingestFailError = "error" // answer 500, as for any other failure
+	ingestFailRetry = "retry" // answer 503 with Retry-After
+	ingestFailSpool = "spool" // append to a local spool file for replay
+)
+
+// insertLogs runs logs through the LOG_ENRICHERS pipeline and stores them,
+// in concurrent chunks when INSERT_CHUNK_SIZE is set.
+// Chunks that failed outright are spooled to disk when the database is
+// unreachable and INGEST_DB_FAILURE_MODE=spool, or else parked in
+// failed_ingestions when dead-lettering is enabled. deadLettered reports that
+// some or all logs were kept for a later replay rather than stored; their
+// IDs are zero. Individual logs the database rejected are never kept, since
+// replaying them would fail again; they come back in a
+// *store.PartialInsertError with no Failed chunks. Otherwise any partial
+// failure returns a *store.PartialInsertError.
+func (h *Handler) insertLogs(ctx context.Context, logs []store.LogEntry) (ids []int64, deadLettered bool, err error) {
+	h.enrichers.Apply(ctx, logs)
+	ids, err = store.InsertLogsConcurrently(ctx, h.repo, logs, h.insertChunkSize, h.insertParallelism)
+	toSpool := h.spool != nil && store.IsUnavailable(err)
+	if err == nil || !(toSpool || h.deadLetter) {
+		return ids, false, err
+	}
+
+	failed := [][]store.LogEntry{logs}
+	var partial *store.PartialInsertError
+	if errors.As(err, &partial) {
+		failed = failed[:0]
+		for _, f := range partial.Failed {
+			failed = append(failed, logs[f.Offset:f.Offset+f.Count])
+		}
+	} else {
+		ids = make([]int64, len(logs))
+	}
+	for _, chunk := range failed {
+		if toSpool {
+			if spErr := h.spool.Append(chunk); spErr != nil {
+				log.Printf("spool %d logs: %v (insert error: %v)", len(chunk), spErr, err)
+				return ids, false, err
+			}
+			log.Printf("spooled %d logs to disk: %v", len(chunk), err)
+			continue
+		}
+		if dlErr := h.repo.SaveFailedIngestion(ctx, chunk, err); dlErr != nil {
+			log.Printf("dead-letter %d logs: %v (insert error: %v)", len(chunk), dlErr, err)
+			return ids, false, err
+		}
+		log.Printf("dead-lettered %d logs: %v", len(chunk), err)
+	}
+	if partial != nil && len(partial.Rows) > 0 {
+		return ids, len(failed) > 0, &store.PartialInsertError{Inserted: partial.Inserted, Rows: partial.Rows}
+	}
+	return ids, len(failed) > 0, nil
+}
+
+// retryLater answers 503 with Retry-After when err means the database is
+// unreachable and INGEST_DB_FAILURE_MODE=retry, so agents hold their logs
+// and send them again. It reports false, writing nothing, otherwise.
+func (h *Handler) retryLater(c echo.Context, err error, resp echo.Map) (bool, error) {
+	if h.ingestFailureMode != ingestFailRetry || !store.IsUnavailable(err) {
+		return false, nil
+	}
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(h.ingestRetryAfter.Seconds())))
+	resp["error"] = "database unavailable, retry later"
+	return true, c.JSON(http.StatusServiceUnavailable, resp)
+}
+
+// ReprocessFailedIngestions replays dead-lettered batches oldest first,
+// deleting each one that inserts. Logs the database rejects individually
+// would fail every time, so they are dropped with the batch and listed in
+// the response instead of being dead-lettered again; a batch that fails as
+// a whole stays for another attempt.
+func (h *Handler) ReprocessFailedIngestions(c echo.Context) error {
+	limit, err := parseLimit(c.QueryParam("limit"), 100, reprocessMaxBatches)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	ctx := c.Request().Context()
+	batches, err := h.repo.ListFailedIngestions(ctx, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list failed ingestions"})
+	}
+
+	reprocessed, failed, logs, rejectedLogs := 0, 0, 0, 0
+	rejected := []rejectedLog{}
+	for _, b := range batches {
+		var entries []store.LogEntry
+		if err := json.Unmarshal(b.Payload, &entries); err != nil {
+			log.Printf("reprocess failed ingestion %d: %v", b.ID, err)
+			failed++
+			continue
+		}
+		stored := len(entries)
+		if len(entries) > 0 {
+			_, err := h.repo.InsertLogs(ctx, entries)
+			var partial *store.PartialInsertError
+			if errors.As(err, &partial) {
+				for _, row := range partial.Rows {
+					rejectedLogs++
+					if len(rejected) < reprocessMaxRejected {
+						rejected = append(rejected, rejectedLog{FailedIngestionID: b.ID, Log: entries[row.Index], Error: row.Err.Error()})
+					}
+				}
+				log.Printf("reprocess failed ingestion %d: dropped %d rejected logs: %v", b.ID, len(partial.Rows), err)
+				stored = partial.Inserted
+			} else if err != nil {
+				if err := h.repo.MarkFailedIngestionRetry(ctx, b.ID, err); err != nil {
+					log.Printf("reprocess failed ingestion %d: %v", b.ID, err)
+				}
+				failed++
+				continue
+			}
+		}
+		if err := h.repo.DeleteFailedIngestion(ctx, b.ID); err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to clear reprocessed ingestion"})
+		}
+		reprocessed++
+		logs += stored
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"reprocessed":   reprocessed,
+		"failed":        failed,
+		"logs":          logs,
+		"rejected_logs": rejectedLogs,
+		"rejected":      rejected,
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/labstack/echo/v4"
+)
+
+// decompressMiddleware inflates request bodies sent with Content-Encoding
+// gzip or zstd before they reach the handler. The inflated body is capped
+// at limit bytes so a small compressed batch cannot expand without bound;
+// past the cap, reads fail with *http.MaxBytesError.
+func decompressMiddleware(limit int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			encoding := strings.ToLower(strings.TrimSpace(req.Header.Get(echo.HeaderContentEncoding)))
+
+			var body io.ReadCloser
+			switch encoding {
+			case "", "identity":
+				return next(c)
+			case "gzip", "x-gzip":
+				zr, err := gzip.NewReader(req.Body)
+				if err != nil {
+					return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid gzip body"})
+				}
+				body = zr
+			case "zstd":
+				zr, err := zstd.NewReader(req.Body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true))
+				if err != nil {
+					return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid zstd body"})
+				}
+				body = zr.IOReadCloser()
+			default:
+				return c.JSON(http.StatusUnsupportedMediaType, echo.Map{"error": fmt.Sprintf("unsupported Content-Encoding %q: use gzip or zstd", encoding)})
+			}
+			defer body.Close()
+
+			req.Body = http.MaxBytesReader(c.Response(), body, limit)
+			req.Header.Del(echo.HeaderContentEncoding)
+			req.Header.Del(echo.HeaderContentLength)
+			req.ContentLength = -1
+			return next(c)
+		}
+	}
+}
+
+// bindError answers a failed Bind: 413 when a decompressed body ran past
+// its cap, otherwise the usual 400.
+func bindError(c echo.Context, err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return c.JSON(http.StatusRequestEntityTooLarge, echo.Map{"error": fmt.Sprintf("decompressed body exceeds %d bytes", maxErr.Limit)})
+	}
+	return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// PutDetectionRuleRequest overrides burst detection for one service. Omitted
+// or zero fields fall back to DETECTION_WINDOW and DETECTION_THRESHOLD.
+type PutDetectionRuleRequest struct {
+	WindowSeconds int `json:"window_seconds" validate:"min=0,max=86400"`
+	Threshold     int `json:"threshold" validate:"min=0"`
+}
+
+func (h *Handler) PutDetectionRule(c echo.Context) error {
+	service := c.Param("service")
+	if service == "" || len(service) > 200 {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid service"})
+	}
+
+	var req PutDetectionRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	rule := &store.DetectionRule{
+		Service:       service,
+		WindowSeconds: req.WindowSeconds,
+		Threshold:     req.Threshold,
+	}
+	if err := h.repo.UpsertDetectionRule(c.Request().Context(), rule); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to save detection rule"})
+	}
+	return c.JSON(http.StatusOK, rule)
+}
+
+func (h *Handler) ListDetectionRules(c echo.Context) error {
+	rules, err := h.repo.ListDetectionRules(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list detection rules"})
+	}
+	return c.JSON(http.StatusOK, rules)
+}
+
+func (h *Handler) DeleteDetectionRule(c echo.Context) error {
+	err := h.repo.DeleteDetectionRule(c.Request().Context(), c.Param("service"))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "detection rule not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to delete detection rule"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// severityDisplays maps incident severity to the presentation hints returned
+// as each incident's "display" object.
+type severityDisplays map[string]store.SeverityDisplay
+
+var defaultSeverityDisplays = severityDisplays{
+	"critical": {Label: "Critical", Color: "#d32f2f", Weight: 4},
+	"high":     {Label: "High", Color: "#f57c00", Weight: 3},
+	"medium":   {Label: "Medium", Color: "#fbc02d", Weight: 2},
+	"low":      {Label: "Low", Color: "#1976d2", Weight: 1},
+}
+
+// parseSeverityDisplay decodes SEVERITY_DISPLAY, a JSON object such as
+// {"critical":{"label":"SEV1","color":"#b00020","weight":4}}. Each entry
+// overrides the default for its severity field by field.
+func parseSeverityDisplay(raw string) (severityDisplays, error) {
+	displays := make(severityDisplays, len(defaultSeverityDisplays))
+	for severity, d := range defaultSeverityDisplays {
+		displays[severity] = d
+	}
+	if raw == "" {
+		return displays, nil
+	}
+	var spec map[string]store.SeverityDisplay
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		return nil, fmt.Errorf("parse severity display: %w", err)
+	}
+	for severity, s := range spec {
+		if _, ok := severityWeight[severity]; !ok {
+			return nil, fmt.Errorf("unknown severity %q", severity)
+		}
+		d := displays[severity]
+		if s.Label != "" {
+			d.Label = s.Label
+		}
+		if s.Color != "" {
+			d.Color = s.Color
+		}
+		if s.Weight != 0 {
+			d.Weight = s.Weight
+		}
+		displays[severity] = d
+	}
+	return displays, nil
+}
+
+// of returns the display hints for a severity, or nil for one without any.
+func (d severityDisplays) of(severity string) *store.SeverityDisplay {
+	display, ok := d[severity]
+	if !ok {
+		return nil
+	}
+	return &display
+}
+
+// apply sets Display on each incident.
+func (d severityDisplays) apply(incidents []store.Incident) {
+	for i := range incidents {
+		incidents[i].Display = d.of(incidents[i].Severity)
+	}
+}
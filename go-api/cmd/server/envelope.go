@@ -0,0 +1,48 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// envelopeMediaType opts a client into wrapped list responses through the
+// Accept header, as an alternative to ?envelope=true.
+const envelopeMediaType = "application/vnd.incident-monitoring.list+json"
+
+// listMeta describes a page of a list response. Total is set only where it
+// is cheap to know, and NextCursor only when another page may follow.
+type listMeta struct {
+	Count      int    `json:"count"`
+	Total      *int64 `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type listEnvelope struct {
+	Data any      `json:"data"`
+	Meta listMeta `json:"meta"`
+}
+
+func wantsEnvelope(c echo.Context) bool {
+	if ok, _ := strconv.ParseBool(c.QueryParam("envelope")); ok {
+		return true
+	}
+	for _, part := range strings.Split(c.Request().Header.Get(echo.HeaderAccept), ",") {
+		if mt, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mt == envelopeMediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// respondList writes a list endpoint's result as the bare array clients
+// expect by default, or wrapped with meta when they opt in.
+func respondList(c echo.Context, data any, meta listMeta) error {
+	if !wantsEnvelope(c) {
+		return jsonWithETag(c, http.StatusOK, data)
+	}
+	return jsonWithETag(c, http.StatusOK, listEnvelope{Data: data, Meta: meta})
+}
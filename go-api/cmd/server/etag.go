@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// jsonWithETag writes v as JSON tagged with a hash of the encoded body, or
+// 304 Not Modified when the client's If-None-Match already has that tag.
+func jsonWithETag(c echo.Context, status int, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "no-cache")
+
+	if etagMatches(c.Request().Header.Get("If-None-Match"), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSONBlob(status, body)
+}
+
+// versionETag is the ETag of a versioned resource such as an incident.
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion reads the version a client expects to be updating from
+// If-Match. ok is false when the header is absent.
+func ifMatchVersion(c echo.Context) (version int64, ok bool, err error) {
+	raw := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if raw == "" {
+		return 0, false, nil
+	}
+	version, err = strconv.ParseInt(strings.Trim(strings.TrimPrefix(raw, "W/"), `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, true, fmt.Errorf("invalid If-Match %q: send the incident's version or ETag", raw)
+	}
+	return version, true, nil
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
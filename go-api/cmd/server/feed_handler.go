@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Link    []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Link      atomLink    `xml:"link"`
+	Category  []atomTerm  `xml:"category"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomTerm struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// IncidentFeed serves the most recently updated incidents as an Atom feed
+// for feed readers and status aggregators. ?severity= (comma separated)
+// narrows it to those severities.
+func (h *Handler) IncidentFeed(c echo.Context) error {
+	var severities []string
+	for _, s := range strings.Split(c.QueryParam("severity"), ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" {
+			continue
+		}
+		if _, ok := severityWeight[s]; !ok {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("unknown severity %q", s)})
+		}
+		severities = append(severities, s)
+	}
+	limit, err := h.pageSize.parse(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	incidents, err := h.repo.ListRecentlyUpdatedIncidents(c.Request().Context(), severities, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list incidents"})
+	}
+
+	base := c.Scheme() + "://" + c.Request().Host
+	self := base + c.Request().URL.RequestURI()
+	feed := atomFeed{
+		ID:      self,
+		Title:   "Incidents",
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Author:  atomPerson{Name: "Incident Monitoring"},
+		Link:    []atomLink{{Rel: "self", Type: "application/atom+xml", Href: self}},
+	}
+	if len(severities) > 0 {
+		feed.Title = "Incidents (" + strings.Join(severities, ", ") + ")"
+	}
+	if len(incidents) > 0 {
+		feed.Updated = incidents[0].UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	for _, inc := range incidents {
+		url := fmt.Sprintf("%s/api/incidents/%d", base, inc.ID)
+		content := inc.Description
+		if inc.Summary != nil && *inc.Summary != "" {
+			content = *inc.Summary
+		}
+		entry := atomEntry{
+			ID:        url,
+			Title:     fmt.Sprintf("[%s] %s", strings.ToUpper(inc.Severity), inc.Description),
+			Updated:   inc.UpdatedAt.UTC().Format(time.RFC3339),
+			Published: inc.CreatedAt.UTC().Format(time.RFC3339),
+			Link:      atomLink{Rel: "alternate", Type: "application/json", Href: url},
+			Category:  []atomTerm{{Term: inc.Severity}, {Term: inc.Status}},
+			Content:   atomContent{Type: "text", Body: content},
+		}
+		if inc.Service != nil {
+			entry.Category = append(entry.Category, atomTerm{Term: *inc.Service})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to render feed"})
+	}
+	return c.Blob(http.StatusOK, "application/atom+xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+var incidentFields = jsonFieldNames(reflect.TypeOf(store.Incident{}))
+
+// jsonFieldNames returns the set of JSON keys a struct type serializes to.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for name := range jsonFieldNames(f.Type) {
+				names[name] = true
+			}
+			continue
+		}
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// parseFields parses a comma-separated ?fields= value, rejecting names that
+// are not in allowed. An empty value means "all fields" and returns nil.
+func parseFields(raw string, allowed map[string]bool) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var fields []string
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !allowed[f] {
+			return nil, fmt.Errorf("unknown field %q", f)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// projectFields reduces each item to the requested JSON fields.
+func projectFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	out := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(b, &all); err != nil {
+			return nil, err
+		}
+		projected := make(map[string]json.RawMessage, len(fields))
+		for _, f := range fields {
+			if v, ok := all[f]; ok {
+				projected[f] = v
+			}
+		}
+		out = append(out, projected)
+	}
+	return out, nil
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"Incident_Monitoring_Project/internal/auth"
+	"Incident_Monitoring_Project/internal/ingestpb"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// startGRPC serves the LogIngestion service on GRPC_ADDR alongside the HTTP
+// API. The returned function stops it, letting open streams finish until ctx
+// expires.
+func startGRPC(cfg Config, h *Handler) (stop func(context.Context), err error) {
+	lis, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		return nil, err
+	}
+	opts := []grpc.ServerOption{grpc.StreamInterceptor(grpcAuthInterceptor(h.repo, cfg.APIKeys, cfg.IngestTokensRequired))}
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			lis.Close()
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	srv := grpc.NewServer(opts...)
+	ingestpb.RegisterLogIngestionServer(srv, &grpcIngestServer{h: h})
+
+	log.Printf("gRPC ingestion listening on %s", cfg.GRPCAddr)
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			log.Printf("grpc server: %v", err)
+		}
+	}()
+	return func(ctx context.Context) {
+		stopped := make(chan struct{})
+		go func() {
+			srv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			srv.Stop()
+		}
+	}, nil
+}
+
+// grpcAuthInterceptor applies the HTTP API's rules to gRPC streams: an
+// x-ingest-token metadata value is checked like X-Ingest-Token, and
+// otherwise an API key is needed in authorization or x-api-key whenever
+// API keys are configured.
+func grpcAuthInterceptor(repo store.Repository, apiKeys map[string]string, tokensRequired bool) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		md, _ := metadata.FromIncomingContext(ctx)
+		first := func(key string) string {
+			if v := md.Get(key); len(v) > 0 {
+				return strings.TrimSpace(v[0])
+			}
+			return ""
+		}
+
+		if presented := first("x-ingest-token"); presented != "" {
+			token, err := repo.LookupServiceToken(ctx, hashServiceToken(presented))
+			if errors.Is(err, store.ErrNotFound) {
+				return status.Error(codes.Unauthenticated, "invalid or revoked ingestion token")
+			}
+			if err != nil {
+				return status.Error(codes.Internal, "failed to verify ingestion token")
+			}
+			ctx = auth.WithPrincipal(auth.WithIngestService(ctx, token.Service), "service:"+token.Service)
+		} else if tokensRequired {
+			return status.Error(codes.Unauthenticated, "ingestion token required")
+		} else if len(apiKeys) > 0 {
+			presented := first("x-api-key")
+			if bearer, ok := strings.CutPrefix(first("authorization"), "Bearer "); ok {
+				presented = strings.TrimSpace(bearer)
+			}
+			name, ok := lookupAPIKey(apiKeys, presented)
+			if !ok {
+				return status.Error(codes.Unauthenticated, "missing or invalid API key")
+			}
+			ctx = auth.WithPrincipal(ctx, name)
+		} else if user := first("x-user"); user != "" {
+			ctx = auth.WithPrincipal(ctx, user)
+		}
+		return handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context { return s.ctx }
+
+type grpcIngestServer struct {
+	ingestpb.UnimplementedLogIngestionServer
+	h *Handler
+}
+
+// IngestLogs answers each batch on the stream in turn. A batch with an
+// invalid log is rejected on its own and the stream carries on; a storage
+// failure ends the stream with Unavailable or Internal, and a batch over
+// INGEST_MAX_IN_FLIGHT with ResourceExhausted, so the agent resends.
+func (s *grpcIngestServer) IngestLogs(stream ingestpb.LogIngestion_IngestLogsServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !s.h.ingestLimit.acquire(1, "grpc") {
+			return status.Error(codes.ResourceExhausted, "too many ingestion requests in flight, retry later")
+		}
+		resp, err := s.h.ingestBatch(stream.Context(), req)
+		s.h.ingestLimit.release(1)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// ingestBatch runs one gRPC batch through the same pipeline as
+// POST /api/logs, within INGEST_TIMEOUT when it is set.
+func (h *Handler) ingestBatch(ctx context.Context, req *ingestpb.IngestLogsRequest) (*ingestpb.IngestLogsResponse, error) {
+	if h.ingestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.ingestTimeout)
+		defer cancel()
+	}
+	resp := &ingestpb.IngestLogsResponse{Count: int32(len(req.Logs))}
+	reject := func(i int, err error) *ingestpb.IngestLogsResponse {
+		resp.Status = "rejected"
+		resp.Error = err.Error()
+		resp.Failed = []*ingestpb.LogError{{Index: int32(i), Error: err.Error()}}
+		return resp
+	}
+	if len(req.Logs) == 0 {
+		resp.Status = "rejected"
+		resp.Error = "logs must not be empty"
+		return resp, nil
+	}
+
+	level := req.Level
+	if level != "" {
+		level = h.normalizeLevel(level)
+	}
+	now := time.Now().UTC()
+	var logs []store.LogEntry
+	var positions []int
+	// Logs failing the ingestion policy are turned away one by one, as over
+	// HTTP; the rest of the batch is still stored.
+	var rejected []*ingestpb.LogError
+	for i, pl := range req.Logs {
+		l := IngestLog{Service: pl.Service, Level: pl.Level, Message: pl.Message}
+		if pl.Timestamp != nil {
+			l.Timestamp = &logTimestamp{Time: pl.Timestamp.AsTime()}
+		}
+		if pl.Metadata != nil {
+			l.Metadata = pl.Metadata.AsMap()
+		}
+		if l.Service == "" {
+			l.Service = req.Service
+		}
+		if err := checkIngestService(ctx, &l); err != nil {
+			return reject(i, err), nil
+		}
+		if l.Level == "" {
+			l.Level = level
+		} else {
+			l.Level = h.normalizeLevel(l.Level)
+		}
+		if err := h.ingestPolicy.apply(&l); err != nil {
+			rejected = append(rejected, &ingestpb.LogError{Index: int32(i), Error: err.Error()})
+			continue
+		}
+		truncateMessage(&l, h.maxMessageLen)
+		clamped := clampFutureTimestamp(&l, now, h.clockSkew)
+		entry, err := l.toEntry(now)
+		if err != nil {
+			return reject(i, err), nil
+		}
+		if clamped {
+			resp.Clamped++
+		}
+		if !keepDebugLog(l, h.debugSampleRate) {
+			resp.SampledOut++
+			continue
+		}
+		logs = append(logs, entry)
+		positions = append(positions, i)
+	}
+	if len(rejected) == len(req.Logs) {
+		resp.Status = "rejected"
+		resp.Error = "every log was rejected"
+		resp.Failed = rejected
+		return resp, nil
+	}
+	logs, positions, dropped := h.applyQuotas(logs, positions, now)
+	for _, n := range dropped {
+		resp.QuotaDropped += int32(n)
+	}
+
+	resp.Status = "accepted"
+	resp.Count = int32(len(logs))
+	resp.Failed = rejected
+	if rejected != nil {
+		resp.Status = "partial"
+	}
+	if len(logs) == 0 {
+		return resp, nil
+	}
+	_, deadLettered, err := h.insertLogs(ctx, logs)
+	var partial *store.PartialInsertError
+	switch {
+	case errors.As(err, &partial) && len(partial.Failed) == 0:
+		resp.Status = "partial"
+		resp.Inserted = int32(partial.Inserted)
+		for _, row := range partial.Rows {
+			resp.Failed = append(resp.Failed, &ingestpb.LogError{Index: int32(positions[row.Index]), Error: row.Err.Error()})
+		}
+		slices.SortFunc(resp.Failed, func(a, b *ingestpb.LogError) int { return int(a.Index - b.Index) })
+	case err != nil:
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, status.Error(codes.DeadlineExceeded, "ingestion timed out; the logs may not have been stored")
+		}
+		if store.IsUnavailable(err) {
+			return nil, status.Error(codes.Unavailable, "database unavailable, retry later")
+		}
+		return nil, status.Error(codes.Internal, "failed to store logs")
+	case deadLettered:
+		resp.Status = "dead_lettered"
+	default:
+		resp.Inserted = int32(len(logs))
+	}
+	return resp, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const defaultHealthTimeout = 2 * time.Second
+
+// healthDependency is an HTTP endpoint probed by the health check. A failing
+// critical dependency makes the service report 503; any other failure only
+// marks it degraded.
+type healthDependency struct {
+	Name     string
+	URL      string
+	Timeout  time.Duration
+	Critical bool
+}
+
+type dependencyStatus struct {
+	Name      string `json:"name"`
+	OK        bool   `json:"ok"`
+	Critical  bool   `json:"critical"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// parseHealthDependencies decodes HEALTH_DEPENDENCIES, a JSON array such as
+// [{"name":"ml","url":"http://python-ml:8000/health","timeout":"2s","critical":true}].
+func parseHealthDependencies(raw string) ([]healthDependency, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var spec []struct {
+		Name     string `json:"name"`
+		URL      string `json:"url"`
+		Timeout  string `json:"timeout"`
+		Critical bool   `json:"critical"`
+	}
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		return nil, fmt.Errorf("parse health dependencies: %w", err)
+	}
+	deps := make([]healthDependency, 0, len(spec))
+	for _, s := range spec {
+		if s.Name == "" || s.URL == "" {
+			return nil, fmt.Errorf("health dependency needs a name and url")
+		}
+		d := healthDependency{Name: s.Name, URL: s.URL, Timeout: defaultHealthTimeout, Critical: s.Critical}
+		if s.Timeout != "" {
+			t, err := time.ParseDuration(s.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("health dependency %s: %w", s.Name, err)
+			}
+			d.Timeout = t
+		}
+		deps = append(deps, d)
+	}
+	return deps, nil
+}
+
+// Health checks the database and every configured dependency concurrently.
+// checks.db is kept for existing consumers; dependencies carries the detail
+// and pool summarises the database connection pool.
+func (h *Handler) Health(c echo.Context) error {
+	ctx := c.Request().Context()
+	statuses := make([]dependencyStatus, len(h.healthDeps)+1)
+
+	var wg sync.WaitGroup
+	wg.Add(len(statuses))
+	go func() {
+		defer wg.Done()
+		statuses[0] = probe(ctx, "db", true, defaultHealthTimeout, func(ctx context.Context) error {
+			_, err := h.repo.ListRecentLogs(ctx, 1)
+			return err
+		})
+	}()
+	for i, dep := range h.healthDeps {
+		go func() {
+			defer wg.Done()
+			statuses[i+1] = probe(ctx, dep.Name, dep.Critical, dep.Timeout, func(ctx context.Context) error {
+				return h.probeHTTP(ctx, dep.URL)
+			})
+		}()
+	}
+	wg.Wait()
+
+	status, code := "ok", http.StatusOK
+	for _, s := range statuses {
+		if s.OK {
+			continue
+		}
+		if s.Critical {
+			status, code = "unavailable", http.StatusServiceUnavailable
+			break
+		}
+		status = "degraded"
+	}
+
+	return c.JSON(code, echo.Map{
+		"status":       status,
+		"checks":       echo.Map{"db": statuses[0].OK},
+		"dependencies": statuses,
+		"pool":         poolSummary(h.repo.PoolStats()),
+	})
+}
+
+func poolSummary(s store.PoolStats) echo.Map {
+	return echo.Map{
+		"acquired":         s.Acquired,
+		"idle":             s.Idle,
+		"total":            s.Total,
+		"max":              s.Max,
+		"wait_count":       s.WaitCount,
+		"wait_duration_ms": s.WaitDuration.Milliseconds(),
+	}
+}
+
+func probe(parent context.Context, name string, critical bool, timeout time.Duration, check func(context.Context) error) dependencyStatus {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	s := dependencyStatus{
+		Name:      name,
+		OK:        err == nil,
+		Critical:  critical,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	return s
+}
+
+func (h *Handler) probeHTTP(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -4,124 +4,601 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/labstack/echo/v4"
 
+	"Incident_Monitoring_Project/internal/auth"
+	"Incident_Monitoring_Project/internal/detection"
+	"Incident_Monitoring_Project/internal/enrich"
+	"Incident_Monitoring_Project/internal/jobs"
+	"Incident_Monitoring_Project/internal/notify"
+	"Incident_Monitoring_Project/internal/sla"
+	"Incident_Monitoring_Project/internal/spool"
 	"Incident_Monitoring_Project/internal/store"
+	"Incident_Monitoring_Project/internal/worker"
 )
 
 type Handler struct {
-	repo        store.Repository
-	mlService   string
-	httpClient  *http.Client
+	repo                  store.Repository
+	mlService             string
+	maxUploadBytes        int64
+	uploadBatchBytes      int
+	debugSampleRate       int
+	maxMessageLen         int
+	maxDescriptionLen     int
+	maxCommentLen         int
+	clockSkew             time.Duration
+	levelAliases          map[string]string
+	enrichers             *enrich.Pipeline
+	ingestPolicy          ingestPolicy
+	logSchemas            logSchemas
+	quotas                *ingestQuotas
+	ingestLimit           *ingestLimiter
+	mlTemplate            *template.Template
+	notifier              *notify.Dispatcher
+	detector              *detection.Detector
+	detectionInterval     time.Duration
+	deadLetter            bool
+	ingestFailureMode     string
+	ingestRetryAfter      time.Duration
+	ingestTimeout         time.Duration
+	spool                 *spool.Spool
+	summaryMaxAge         time.Duration
+	mlMinSeverity         string
+	mlAllowEmptyRootCause bool
+	metadataKeys          map[string]bool
+	insertChunkSize       int
+	insertParallelism     int
+	pageSize              pageSize
+	slaTargets            sla.Targets
+	healthDeps            []healthDependency
+	httpClient            *http.Client
+	metaCache             *valueCache
+	severityDisplay       severityDisplays
+	maintenanceTimeout    time.Duration
+	workers               *worker.Manager
+	workerToggles         bool
+	mlConcurrency         int
+	jobs                  *jobs.Manager
 }
 
-func NewHandler(repo store.Repository, mlService string) *Handler {
-	return &Handler{
-		repo:      repo,
-		mlService: mlService,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+func NewHandler(repo store.Repository, cfg Config, mlTemplate *template.Template, notifier *notify.Dispatcher, detector *detection.Detector, slaTargets sla.Targets, mlClient *http.Client, healthDeps []healthDependency, levelAliases map[string]string, severityDisplay severityDisplays, enrichers *enrich.Pipeline, ingestPolicy ingestPolicy, logSchemas logSchemas, quotas *ingestQuotas, ingestLimit *ingestLimiter, workers *worker.Manager, jobManager *jobs.Manager) *Handler {
+	h := &Handler{
+		repo:                  repo,
+		mlService:             cfg.MLServiceURL,
+		maxUploadBytes:        cfg.MaxUploadBytes,
+		uploadBatchBytes:      cfg.UploadBatchBytes,
+		debugSampleRate:       cfg.DebugSampleRate,
+		maxMessageLen:         cfg.MaxMessageLength,
+		maxDescriptionLen:     cfg.MaxDescriptionLength,
+		maxCommentLen:         cfg.MaxCommentLength,
+		clockSkew:             cfg.ClockSkewTolerance,
+		levelAliases:          levelAliases,
+		severityDisplay:       severityDisplay,
+		enrichers:             enrichers,
+		ingestPolicy:          ingestPolicy,
+		logSchemas:            logSchemas,
+		quotas:                quotas,
+		ingestLimit:           ingestLimit,
+		mlTemplate:            mlTemplate,
+		notifier:              notifier,
+		detector:              detector,
+		detectionInterval:     cfg.DetectionInterval,
+		deadLetter:            cfg.DeadLetterEnabled,
+		ingestFailureMode:     cfg.IngestFailureMode,
+		ingestRetryAfter:      cfg.IngestRetryAfter,
+		ingestTimeout:         cfg.IngestTimeout,
+		summaryMaxAge:         cfg.SummaryMaxAge,
+		mlMinSeverity:         cfg.MLMinSeverity,
+		mlAllowEmptyRootCause: cfg.MLAllowEmptyRootCause,
+		metadataKeys:          make(map[string]bool, len(cfg.IndexedMetadataKeys)),
+		insertChunkSize:       cfg.InsertChunkSize,
+		insertParallelism:     cfg.InsertParallelism,
+		pageSize:              cfg.PageSize,
+		slaTargets:            slaTargets,
+		httpClient:            mlClient,
+		healthDeps:            healthDeps,
+		metaCache:             newValueCache(cfg.MetaCacheTTL),
+		maintenanceTimeout:    cfg.MaintenanceTimeout,
+		workers:               workers,
+		workerToggles:         cfg.WorkerToggles,
+		mlConcurrency:         cfg.MLReanalyzeConcurrency,
+		jobs:                  jobManager,
 	}
+	for _, key := range cfg.IndexedMetadataKeys {
+		h.metadataKeys[key] = true
+	}
+	if cfg.IngestFailureMode == ingestFailSpool {
+		h.spool = spool.New(cfg.IngestSpoolPath)
+	}
+	return h
+}
+
+type IngestLog struct {
+	Timestamp *logTimestamp  `json:"timestamp"`
+	Service   string         `json:"service" validate:"max=200"`
+	Level     string         `json:"level" validate:"omitempty,oneof=debug info warn warning error critical fatal panic"`
+	Message   string         `json:"message" validate:"max=10000"`
+	Metadata  map[string]any `json:"metadata"`
 }
 
+// IngestLogRequest may carry batch-level Service and Level defaults, applied
+// to every log that omits its own.
 type IngestLogRequest struct {
-	Logs []struct {
-		Timestamp *time.Time       `json:"timestamp"`
-		Service   string           `json:"service"`
-		Level     string           `json:"level"`
-		Message   string           `json:"message"`
-		Metadata  map[string]any   `json:"metadata"`
-	} `json:"logs"`
+	Service string      `json:"service" validate:"omitempty,max=200"`
+	Level   string      `json:"level" validate:"omitempty,oneof=debug info warn warning error critical fatal panic"`
+	Logs    []IngestLog `json:"logs" validate:"required,min=1,dive"`
+}
+
+type CreateIncidentRequest struct {
+	Severity    string   `json:"severity" validate:"required,oneof=low medium high critical"`
+	Description string   `json:"description" validate:"required"`
+	Service     *string  `json:"service" validate:"omitempty,max=200"`
+	Tags        []string `json:"tags"`
+	ExternalID  *string  `json:"external_id" validate:"omitempty,max=200"`
+}
+
+type UpdateIncidentStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=open acknowledged resolved"`
+}
+
+// toEntry validates a single log and converts it to its stored form. It is
+// shared by the JSON and file-upload ingestion paths.
+func (l IngestLog) toEntry(now time.Time) (store.LogEntry, error) {
+	if err := validate.Validate(l); err != nil {
+		return store.LogEntry{}, errors.New(joinProblems(fieldProblems(err)))
+	}
+	ts := now
+	if l.Timestamp != nil && l.Timestamp.Invalid == "" {
+		ts = l.Timestamp.Time
+	}
+	metaBytes, _ := json.Marshal(l.Metadata)
+	return store.LogEntry{
+		Timestamp: ts,
+		Service:   l.Service,
+		Level:     l.Level,
+		Message:   l.Message,
+		Metadata:  metaBytes,
+	}, nil
 }
 
 func (h *Handler) IngestLogs(c echo.Context) error {
+	returnIDs, _ := strconv.ParseBool(c.QueryParam("return_ids"))
+
+	reason, err := h.logSchemas.prepare(c.Request())
+	if err != nil {
+		return bindError(c, err)
+	}
+	if reason != "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": reason})
+	}
+
 	var req IngestLogRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+		return bindError(c, err)
 	}
 
-	if len(req.Logs) == 0 {
-		return c.JSON(http.StatusBadRequest, echo.Map{"error": "no logs provided"})
+	if req.Level != "" {
+		req.Level = h.normalizeLevel(req.Level)
+	}
+	// Logs failing the ingestion policy are turned away one by one; the
+	// rest of the batch is still stored.
+	var rejected []echo.Map
+	skip := make([]bool, len(req.Logs))
+	for i := range req.Logs {
+		l := &req.Logs[i]
+		if l.Service == "" {
+			l.Service = req.Service
+		}
+		if err := checkIngestService(c.Request().Context(), l); err != nil {
+			return c.JSON(http.StatusForbidden, echo.Map{"error": fmt.Sprintf("logs[%d]: %s", i, err)})
+		}
+		if l.Level == "" {
+			l.Level = req.Level
+		} else {
+			l.Level = h.normalizeLevel(l.Level)
+		}
+		if err := h.ingestPolicy.apply(l); err != nil {
+			rejected = append(rejected, echo.Map{"index": i, "error": err.Error()})
+			skip[i] = true
+			continue
+		}
+		truncateMessage(l, h.maxMessageLen)
+	}
+	if len(rejected) == len(req.Logs) {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "every log was rejected", "failed": rejected})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
 	}
 
 	var logs []store.LogEntry
+	var positions []int
 	now := time.Now().UTC()
-
-	validLevels := map[string]bool{
-		"debug":    true,
-		"info":     true,
-		"warn":     true,
-		"warning":  true,
-		"error":    true,
-		"critical": true,
-		"fatal":    true,
-		"panic":    true,
-	}
+	sampledOut, clamped := 0, 0
+	var fallbacks []echo.Map
 
 	for i, l := range req.Logs {
-		if l.Service == "" {
-			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("log %d: service is required", i)})
+		if skip[i] {
+			continue
 		}
-		if l.Message == "" {
-			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("log %d: message is required", i)})
+		if clampFutureTimestamp(&l, now, h.clockSkew) {
+			clamped++
 		}
-		if len(l.Message) > 10000 {
-			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("log %d: message exceeds 10000 characters", i)})
+		entry, err := l.toEntry(now)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
 		}
-		if !validLevels[l.Level] {
-			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("log %d: invalid level '%s'", i, l.Level)})
+		if warning := l.timestampFallback(); warning != "" {
+			fallbacks = append(fallbacks, echo.Map{"index": i, "warning": warning})
 		}
-
-		ts := now
-		if l.Timestamp != nil {
-			ts = *l.Timestamp
+		if !keepDebugLog(l, h.debugSampleRate) {
+			sampledOut++
+			continue
 		}
-		metaBytes, _ := json.Marshal(l.Metadata)
-		logs = append(logs, store.LogEntry{
-			Timestamp: ts,
-			Service:   l.Service,
-			Level:     l.Level,
-			Message:   l.Message,
-			Metadata:  string(metaBytes),
-		})
+		logs = append(logs, entry)
+		positions = append(positions, i)
 	}
+	logs, positions, quotaDropped := h.applyQuotas(logs, positions, now)
 
 	ctx := c.Request().Context()
-	if err := h.repo.InsertLogs(ctx, logs); err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to store logs"})
+	ids := []int64{}
+	deadLettered := false
+	if len(logs) > 0 {
+		var err error
+		if ids, deadLettered, err = h.insertLogs(ctx, logs); err != nil {
+			var partial *store.PartialInsertError
+			if !errors.As(err, &partial) {
+				if retry, resp := h.retryLater(c, err, echo.Map{}); retry {
+					return resp
+				}
+				return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to store logs"})
+			}
+			failed := rejected
+			for _, row := range partial.Rows {
+				failed = append(failed, echo.Map{"index": positions[row.Index], "error": row.Err.Error()})
+			}
+			slices.SortFunc(failed, func(a, b echo.Map) int { return a["index"].(int) - b["index"].(int) })
+			if len(partial.Failed) > 0 {
+				resp := echo.Map{"error": "failed to store logs", "inserted": partial.Inserted, "failed": failed}
+				if retry, rerr := h.retryLater(c, err, resp); retry {
+					return rerr
+				}
+				return c.JSON(http.StatusInternalServerError, resp)
+			}
+			// Only individual logs were rejected; report which alongside
+			// what was stored.
+			resp := echo.Map{"status": "partial", "count": len(logs), "inserted": partial.Inserted, "sampled_out": sampledOut, "clamped": clamped, "failed": failed}
+			if fallbacks != nil {
+				resp["timestamp_fallbacks"] = fallbacks
+			}
+			if quotaDropped != nil {
+				resp["quota_dropped"] = quotaDropped
+			}
+			if deadLettered {
+				resp["dead_lettered"] = true
+			} else if returnIDs {
+				resp["ids"] = ids
+			}
+			return c.JSON(http.StatusMultiStatus, resp)
+		}
 	}
 
-	return c.JSON(http.StatusAccepted, echo.Map{"status": "accepted", "count": len(logs)})
+	resp := echo.Map{"status": "accepted", "count": len(logs), "sampled_out": sampledOut, "clamped": clamped}
+	if fallbacks != nil {
+		resp["timestamp_fallbacks"] = fallbacks
+	}
+	if quotaDropped != nil {
+		resp["quota_dropped"] = quotaDropped
+	}
+	code := http.StatusAccepted
+	if rejected != nil {
+		resp["status"] = "partial"
+		resp["failed"] = rejected
+		code = http.StatusMultiStatus
+	}
+	if deadLettered {
+		resp["status"] = "dead_lettered"
+	} else if returnIDs {
+		resp["ids"] = ids
+	}
+	return c.JSON(code, resp)
 }
 
-func (h *Handler) Health(c echo.Context) error {
-	ctx, cancel := context.WithTimeout(c.Request().Context(), 3*time.Second)
-	defer cancel()
+// ListLogs pages through logs newest first, or oldest first with
+// ?order=asc. Pass the last returned ID as ?cursor= to fetch the next page.
+// Indexed metadata keys filter as ?meta.<key>=<value>.
+func (h *Handler) ListLogs(c echo.Context) error {
+	q := store.LogQuery{Service: c.QueryParam("service")}
+	for name, values := range c.QueryParams() {
+		key, ok := strings.CutPrefix(name, "meta.")
+		if !ok {
+			continue
+		}
+		if !h.metadataKeys[key] {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("metadata key %q is not indexed", key)})
+		}
+		if q.Metadata == nil {
+			q.Metadata = make(map[string]string)
+		}
+		q.Metadata[key] = values[0]
+	}
+	switch c.QueryParam("order") {
+	case "", "desc":
+	case "asc":
+		q.Ascending = true
+	default:
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "order must be asc or desc"})
+	}
+	if raw := c.QueryParam("cursor"); raw != "" {
+		cursor, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || cursor <= 0 {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid cursor"})
+		}
+		q.Cursor = cursor
+	}
+	limit, err := h.pageSize.parse(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	q.Limit = limit
+
+	logs, err := h.repo.ListLogs(c.Request().Context(), q)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list logs"})
+	}
+	meta := listMeta{Count: len(logs)}
+	if len(logs) == q.Limit {
+		meta.NextCursor = strconv.FormatInt(logs[len(logs)-1].ID, 10)
+	}
+	return respondList(c, logs, meta)
+}
 
-	_, err := h.repo.ListRecentLogs(ctx, 1)
-	dbOK := err == nil
+func (h *Handler) GetLog(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid log id"})
+	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"status": "ok",
-		"checks": echo.Map{
-			"db": dbOK,
-		},
-	})
+	entry, err := h.repo.GetLog(c.Request().Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "log not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load log"})
+	}
+	return c.JSON(http.StatusOK, entry)
+}
+
+const (
+	logContextDefault = 50
+	logContextMax     = 500
+)
+
+// GetLogContext returns a log with up to ?before= and ?after= (default 50,
+// max 500) neighbouring logs from the same service.
+func (h *Handler) GetLogContext(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid log id"})
+	}
+	count := func(name string) (int, error) {
+		raw := c.QueryParam(name)
+		if raw == "" {
+			return logContextDefault, nil
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid %s %q", name, raw)
+		}
+		return min(n, logContextMax), nil
+	}
+	before, err := count("before")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	after, err := count("after")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	res, err := h.repo.LogContext(c.Request().Context(), id, before, after)
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "log not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load log context"})
+	}
+	return c.JSON(http.StatusOK, res)
 }
 
 func (h *Handler) ListIncidents(c echo.Context) error {
+	fields, err := parseFields(c.QueryParam("fields"), incidentFields)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if raw := c.QueryParam("ids"); raw != "" {
+		return h.incidentsByIDs(c, raw, fields)
+	}
+
+	sort := store.IncidentSort(c.QueryParam("sort"))
+	switch sort {
+	case "":
+		sort = store.SortByCreated
+	case store.SortByCreated, store.SortByUpdated:
+	default:
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "sort must be created_at or updated_at"})
+	}
+
+	limit, err := h.pageSize.parse(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
 	ctx := c.Request().Context()
-	incidents, err := h.repo.ListIncidents(ctx, 100)
+	service := c.QueryParam("service")
+	var incidents []store.Incident
+	if service != "" {
+		incidents, err = h.repo.ListServiceIncidents(ctx, service, limit, sort)
+	} else {
+		incidents, err = h.repo.ListIncidents(ctx, limit, sort)
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list incidents"})
 	}
-	return c.JSON(http.StatusOK, incidents)
+	h.slaTargets.Apply(incidents, time.Now())
+	h.severityDisplay.apply(incidents)
+
+	meta := listMeta{Count: len(incidents)}
+	if wantsEnvelope(c) {
+		total, err := h.repo.CountIncidents(ctx, service)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to count incidents"})
+		}
+		meta.Total = &total
+	}
+	if fields == nil {
+		return respondList(c, incidents, meta)
+	}
+
+	projected, err := projectFields(incidents, fields)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to encode incidents"})
+	}
+	return respondList(c, projected, meta)
+}
+
+const recentIncidentsMaxWindow = 7 * 24 * time.Hour
+
+// RecentIncidents lists incidents created within ?window= (default 1h,
+// capped at recentIncidentsMaxWindow), newest first.
+func (h *Handler) RecentIncidents(c echo.Context) error {
+	window := time.Hour
+	if raw := c.QueryParam("window"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("invalid window %q: use a positive duration like 1h", raw)})
+		}
+		window = min(d, recentIncidentsMaxWindow)
+	}
+
+	incidents, err := h.repo.ListIncidentsSince(c.Request().Context(), time.Now().UTC().Add(-window))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list incidents"})
+	}
+	h.slaTargets.Apply(incidents, time.Now())
+	h.severityDisplay.apply(incidents)
+	total := int64(len(incidents))
+	return respondList(c, incidents, listMeta{Count: len(incidents), Total: &total})
+}
+
+// incidentBatchMaxIDs bounds how many incidents one ?ids= lookup returns.
+const incidentBatchMaxIDs = 100
+
+// incidentsByIDs answers ListIncidents for ?ids=1,2,3, returning the
+// incidents in the order asked for and skipping IDs that don't exist.
+func (h *Handler) incidentsByIDs(c echo.Context, raw string, fields []string) error {
+	parts := strings.Split(raw, ",")
+	if len(parts) > incidentBatchMaxIDs {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("at most %d ids per request", incidentBatchMaxIDs)})
+	}
+	ids := make([]int64, 0, len(parts))
+	for _, p := range parts {
+		id, err := strconv.ParseInt(strings.TrimSpace(p), 10, 64)
+		if err != nil || id <= 0 {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("invalid incident id %q", p)})
+		}
+		ids = append(ids, id)
+	}
+
+	incidents, err := h.repo.GetIncidentsByIDs(c.Request().Context(), ids)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load incidents"})
+	}
+	if incidents == nil {
+		incidents = []store.Incident{}
+	}
+	h.slaTargets.Apply(incidents, time.Now())
+	h.severityDisplay.apply(incidents)
+
+	meta := listMeta{Count: len(incidents)}
+	if fields == nil {
+		return respondList(c, incidents, meta)
+	}
+	projected, err := projectFields(incidents, fields)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to encode incidents"})
+	}
+	return respondList(c, projected, meta)
+}
+
+// UnanalyzedIncidents lists incidents still waiting for an ML summary, most
+// severe and oldest first, so a backfill worker can work through them.
+func (h *Handler) UnanalyzedIncidents(c echo.Context) error {
+	limit, err := h.pageSize.parse(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	incidents, err := h.repo.ListUnanalyzedIncidents(c.Request().Context(), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list incidents"})
+	}
+	if incidents == nil {
+		incidents = []store.Incident{}
+	}
+	h.slaTargets.Apply(incidents, time.Now())
+	h.severityDisplay.apply(incidents)
+	return respondList(c, incidents, listMeta{Count: len(incidents)})
+}
+
+func (h *Handler) CreateIncident(c echo.Context) error {
+	var req CreateIncidentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+	description, err := cleanText("description", req.Description, h.maxDescriptionLen)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	inc := &store.Incident{
+		Status:      "open",
+		Severity:    req.Severity,
+		Description: description,
+		Service:     req.Service,
+		Tags:        tags,
+		ExternalID:  req.ExternalID,
+	}
+	created, err := h.repo.CreateIncident(c.Request().Context(), inc)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to create incident"})
+	}
+	if !created {
+		// A retry of an earlier request with the same external_id.
+		inc.SLA = h.slaTargets.Evaluate(inc, time.Now())
+		inc.Display = h.severityDisplay.of(inc.Severity)
+		return c.JSON(http.StatusOK, inc)
+	}
+	h.notifyIncidentOpened(inc)
+	inc.Display = h.severityDisplay.of(inc.Severity)
+	return c.JSON(http.StatusCreated, inc)
 }
 
 func (h *Handler) UpdateIncidentStatus(c echo.Context) error {
@@ -131,19 +608,134 @@ func (h *Handler) UpdateIncidentStatus(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
 	}
 
-	var req struct {
-		Status string `json:"status"`
+	version, ok, err := ifMatchVersion(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if !ok {
+		return c.JSON(http.StatusPreconditionRequired, echo.Map{"error": "If-Match with the incident's current version is required"})
 	}
+
+	var req UpdateIncidentStatusRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
 	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
 
 	ctx := c.Request().Context()
-	if err := h.repo.UpdateIncidentStatus(ctx, id, req.Status); err != nil {
+	if req.Status == "acknowledged" {
+		version, err = h.repo.AcknowledgeIncident(ctx, id, requestUser(c), version)
+	} else {
+		version, err = h.repo.UpdateIncidentStatus(ctx, id, req.Status, version)
+	}
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	case errors.Is(err, store.ErrStaleVersion):
+		return c.JSON(http.StatusConflict, echo.Map{"error": "incident was modified by someone else; reload and retry"})
+	case err != nil:
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to update status"})
 	}
+	if err := h.repo.AddIncidentEvent(ctx, id, "status_changed", req.Status, auth.Actor(ctx)); err != nil {
+		log.Printf("record status change for incident %d: %v", id, err)
+	}
+
+	h.notifyWatchers(id, fmt.Sprintf("Incident #%d is now %s", id, req.Status), "")
+
+	c.Response().Header().Set("ETag", versionETag(version))
+	return c.JSON(http.StatusOK, echo.Map{"status": "updated", "version": version})
+}
+
+type incidentDetail struct {
+	store.Incident
+	Links    []store.IncidentLink    `json:"links"`
+	Watchers []string                `json:"watchers"`
+	Related  []store.RelatedIncident `json:"related"`
+}
+
+func (h *Handler) GetIncident(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	incident, err := h.repo.GetIncident(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+	return h.respondIncidentDetail(c, incident)
+}
+
+// GetIncidentByExternalID looks an incident up by the external_id it was
+// created with.
+func (h *Handler) GetIncidentByExternalID(c echo.Context) error {
+	incident, err := h.repo.GetIncidentByExternalID(c.Request().Context(), c.Param("external_id"))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load incident"})
+	}
+	return h.respondIncidentDetail(c, incident)
+}
+
+func (h *Handler) respondIncidentDetail(c echo.Context, incident *store.Incident) error {
+	ctx := c.Request().Context()
+	id := incident.ID
+	links, err := h.repo.ListIncidentLinks(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load incident links"})
+	}
+	watchers, err := h.repo.ListIncidentWatchers(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load incident watchers"})
+	}
+	related, err := h.repo.ListRelatedIncidents(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load related incidents"})
+	}
+
+	incident.SLA = h.slaTargets.Evaluate(incident, time.Now())
+	incident.Display = h.severityDisplay.of(incident.Severity)
+	c.Response().Header().Set("ETag", versionETag(incident.Version))
+	return c.JSON(http.StatusOK, incidentDetail{Incident: *incident, Links: links, Watchers: watchers, Related: related})
+}
+
+func (h *Handler) ListIncidentEvents(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	events, err := h.repo.ListIncidentEvents(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list events"})
+	}
+	return c.JSON(http.StatusOK, events)
+}
 
-	return c.JSON(http.StatusOK, echo.Map{"status": "updated"})
+// IncidentAudit lists every recorded write to the incident, oldest first,
+// with the actor and the fields it changed.
+func (h *Handler) IncidentAudit(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.repo.GetIncident(ctx, id); err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+	entries, err := h.repo.ListIncidentAudit(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list audit entries"})
+	}
+	if entries == nil {
+		entries = []store.IncidentAuditEntry{}
+	}
+	return c.JSON(http.StatusOK, entries)
 }
 
 func (h *Handler) GetIncidentSummary(c echo.Context) error {
@@ -159,44 +751,125 @@ func (h *Handler) GetIncidentSummary(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
 	}
 
-	if incident.Summary != nil && incident.RootCause != nil {
+	refreshStale, _ := strconv.ParseBool(c.QueryParam("refresh_stale"))
+	if h.hasAnalysis(incident) && !(refreshStale && h.summaryStale(incident)) {
 		return c.JSON(http.StatusOK, incident)
 	}
+	force, _ := strconv.ParseBool(c.QueryParam("force"))
+	if !force && h.mlMinSeverity != "" && severityWeight[incident.Severity] < severityWeight[h.mlMinSeverity] {
+		return c.JSON(http.StatusOK, summaryResponse{
+			Incident: incident,
+			Note:     fmt.Sprintf("analysis skipped: %s is below the %s minimum severity (use ?force=true to analyze anyway)", incident.Severity, h.mlMinSeverity),
+		})
+	}
 
-	reqBody := map[string]any{
-		"incident_id": id,
-		"description": incident.Description,
+	summary, rootCause, reason, err := h.analyze(ctx, incident)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
+	}
+	if reason != "" {
+		return h.summaryUnavailable(c, incident, reason)
+	}
+
+	if err := h.repo.UpdateIncidentSummary(ctx, id, summary, rootCause); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to save summary"})
+	}
+
+	now := time.Now().UTC()
+	incident.Summary = &summary
+	incident.RootCause = &rootCause
+	incident.SummaryUpdatedAt = &now
+
+	return c.JSON(http.StatusOK, incident)
+}
+
+// analyze asks the ML service for an incident's analysis and waits for it.
+// A non-empty reason means the ML service failed to produce one; err means
+// the request couldn't be made at all.
+func (h *Handler) analyze(ctx context.Context, incident *store.Incident) (summary, rootCause, reason string, err error) {
+	bodyBytes, err := h.buildMLRequest(ctx, incident)
+	if err != nil {
+		return "", "", "", errors.New("failed to build ML request")
 	}
-	bodyBytes, _ := json.Marshal(reqBody)
 
 	url := fmt.Sprintf("%s/analyze_incident", h.mlService)
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to create ML request"})
+		return "", "", "", errors.New("failed to create ML request")
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := h.httpClient.Do(httpReq)
-	if err != nil || resp.StatusCode >= 300 {
-		return c.JSON(http.StatusBadGateway, echo.Map{"error": "ML service unavailable"})
+	if err != nil {
+		return "", "", "ML service unavailable", nil
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", "", "ML service unavailable", nil
+	}
 
 	var mlResp struct {
 		Summary   string `json:"summary"`
 		RootCause string `json:"root_cause"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&mlResp); err != nil {
-		return c.JSON(http.StatusBadGateway, echo.Map{"error": "invalid ML response"})
+		return "", "", "invalid ML response", nil
 	}
+	if h.emptyAnalysis(mlResp.Summary, mlResp.RootCause) {
+		return "", "", emptyAnalysisReason, nil
+	}
+	return mlResp.Summary, mlResp.RootCause, "", nil
+}
 
-	if err := h.repo.UpdateIncidentSummary(ctx, id, mlResp.Summary, mlResp.RootCause); err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to save summary"})
+// summaryResponse is an incident returned by GetIncidentSummary with a note
+// on why it carries no fresh analysis. Stale marks a previous analysis
+// served because a new one couldn't be produced.
+type summaryResponse struct {
+	*store.Incident
+	Stale bool   `json:"stale,omitempty"`
+	Note  string `json:"note,omitempty"`
+}
+
+// summaryUnavailable answers a failed ML analysis with the incident's
+// previous summary when it has one, and 502 only when there is nothing to
+// fall back on.
+func (h *Handler) summaryUnavailable(c echo.Context, incident *store.Incident, reason string) error {
+	if !h.hasAnalysis(incident) {
+		return c.JSON(http.StatusBadGateway, echo.Map{"error": reason})
 	}
+	return c.JSON(http.StatusOK, summaryResponse{
+		Incident: incident,
+		Stale:    true,
+		Note:     reason + "; returning the previous analysis",
+	})
+}
 
-	incident.Summary = &mlResp.Summary
-	incident.RootCause = &mlResp.RootCause
+// emptyAnalysisReason is reported when the ML service answers without an
+// analysis; nothing is saved, so the next request tries again.
+const emptyAnalysisReason = "ML analysis produced no result; try again later"
 
-	return c.JSON(http.StatusOK, incident)
+// emptyAnalysis reports whether an ML result is too blank to keep: always
+// when the summary is, and when the root cause is unless
+// ML_ALLOW_EMPTY_ROOT_CAUSE is set.
+func (h *Handler) emptyAnalysis(summary, rootCause string) bool {
+	return strings.TrimSpace(summary) == "" || (!h.mlAllowEmptyRootCause && strings.TrimSpace(rootCause) == "")
+}
+
+// hasAnalysis reports whether the incident carries a usable analysis. Blank
+// results saved before they were rejected don't count, so they get redone.
+func (h *Handler) hasAnalysis(inc *store.Incident) bool {
+	return inc.Summary != nil && inc.RootCause != nil && !h.emptyAnalysis(*inc.Summary, *inc.RootCause)
 }
 
+// summaryStale reports whether a cached analysis predates the incident's
+// latest occurrence or is older than the configured maximum age. Summaries
+// saved before their timestamp was tracked are always stale.
+func (h *Handler) summaryStale(inc *store.Incident) bool {
+	if inc.SummaryUpdatedAt == nil {
+		return true
+	}
+	if inc.LastSeenAt != nil && inc.LastSeenAt.After(*inc.SummaryUpdatedAt) {
+		return true
+	}
+	return h.summaryMaxAge > 0 && time.Since(*inc.SummaryUpdatedAt) > h.summaryMaxAge
+}
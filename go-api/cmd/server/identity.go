@@ -0,0 +1,14 @@
+package main
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/auth"
+)
+
+// requestUser returns the principal the auth middleware resolved for this
+// request, or "" when the request is anonymous.
+func requestUser(c echo.Context) string {
+	p, _ := auth.Principal(c.Request().Context())
+	return p
+}
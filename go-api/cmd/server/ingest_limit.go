@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/sync/semaphore"
+
+	"Incident_Monitoring_Project/internal/metrics"
+)
+
+// ingestBusyRetryAfter is the Retry-After, in seconds, sent to an
+// ingestion request turned away at the in-flight limit.
+const ingestBusyRetryAfter = 1
+
+// ingestLimiter bounds how much ingestion work runs at once so a burst of
+// writers cannot take every pool connection and starve reads. Each request
+// holds its weight of the capacity for as long as it runs: a POST /api/logs
+// batch or gRPC batch weighs 1, an upload weighs uploadWeight.
+type ingestLimiter struct {
+	sem          *semaphore.Weighted
+	capacity     int64
+	uploadWeight int64
+	metrics      *metrics.Registry
+	inFlight     atomic.Int64
+}
+
+// newIngestLimiter builds the limiter for INGEST_MAX_IN_FLIGHT and
+// INGEST_UPLOAD_WEIGHT; a capacity of 0 turns limiting off. An upload
+// weight above the capacity is lowered to it, or no upload could run.
+func newIngestLimiter(capacity, uploadWeight int64, reg *metrics.Registry) (*ingestLimiter, error) {
+	if capacity < 0 {
+		return nil, fmt.Errorf("limit must not be negative, got %d", capacity)
+	}
+	if uploadWeight < 1 {
+		return nil, fmt.Errorf("upload weight must be at least 1, got %d", uploadWeight)
+	}
+	if capacity > 0 {
+		uploadWeight = min(uploadWeight, capacity)
+	}
+	l := &ingestLimiter{capacity: capacity, uploadWeight: uploadWeight, metrics: reg}
+	if capacity > 0 {
+		l.sem = semaphore.NewWeighted(capacity)
+	}
+	l.record()
+	return l, nil
+}
+
+// acquire takes weight of the capacity if it is free right now; ingestion
+// never queues for it.
+func (l *ingestLimiter) acquire(weight int64, route string) bool {
+	if l.sem != nil && !l.sem.TryAcquire(weight) {
+		l.metrics.Counter("ingest_rejected_total", "Ingestion requests turned away at INGEST_MAX_IN_FLIGHT.", "route", route).Inc()
+		return false
+	}
+	l.inFlight.Add(1)
+	l.record()
+	return true
+}
+
+func (l *ingestLimiter) release(weight int64) {
+	if l.sem != nil {
+		l.sem.Release(weight)
+	}
+	l.inFlight.Add(-1)
+	l.record()
+}
+
+func (l *ingestLimiter) record() {
+	l.metrics.Gauge("ingest_in_flight", "Ingestion requests currently being handled.").Set(float64(l.inFlight.Load()))
+}
+
+// middleware holds the request's weight while it runs, answering 429 with
+// Retry-After when there is not enough capacity left.
+func (l *ingestLimiter) middleware(upload bool) echo.MiddlewareFunc {
+	weight := int64(1)
+	if upload {
+		weight = l.uploadWeight
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !l.acquire(weight, c.Path()) {
+				c.Response().Header().Set("Retry-After", strconv.Itoa(ingestBusyRetryAfter))
+				return c.JSON(http.StatusTooManyRequests, echo.Map{"error": "too many ingestion requests in flight, retry later"})
+			}
+			defer l.release(weight)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ingestFields are the log fields an ingestion policy can require or
+// default, in the order they are checked.
+var ingestFields = []string{"service", "level", "message"}
+
+// ingestPolicy fills in missing log fields from configured defaults and then
+// rejects logs still lacking a required one.
+type ingestPolicy struct {
+	required map[string]bool
+	defaults map[string]string
+}
+
+// parseIngestPolicy reads INGEST_REQUIRED_FIELDS, a list of fields that
+// defaults to all of them ("none" requires none), and INGEST_FIELD_DEFAULTS,
+// a JSON object such as {"level":"info"}. A default level must be a
+// canonical level.
+func parseIngestPolicy(required []string, rawDefaults string) (ingestPolicy, error) {
+	switch {
+	case len(required) == 0:
+		required = ingestFields
+	case len(required) == 1 && strings.EqualFold(required[0], "none"):
+		required = nil
+	}
+	p := ingestPolicy{required: make(map[string]bool, len(required)), defaults: map[string]string{}}
+	for _, f := range required {
+		f = strings.ToLower(f)
+		if !slices.Contains(ingestFields, f) {
+			return ingestPolicy{}, fmt.Errorf("unknown field %q: use %s", f, strings.Join(ingestFields, ", "))
+		}
+		p.required[f] = true
+	}
+	if rawDefaults == "" {
+		return p, nil
+	}
+	if err := json.Unmarshal([]byte(rawDefaults), &p.defaults); err != nil {
+		return ingestPolicy{}, fmt.Errorf("parse field defaults: %w", err)
+	}
+	for f, v := range p.defaults {
+		if !slices.Contains(ingestFields, f) {
+			return ingestPolicy{}, fmt.Errorf("unknown field %q: use %s", f, strings.Join(ingestFields, ", "))
+		}
+		if f == "level" && !logLevels[v] {
+			return ingestPolicy{}, fmt.Errorf("default level %q is not a canonical level", v)
+		}
+	}
+	return p, nil
+}
+
+// apply fills the log's blank fields from the defaults and reports the
+// first required field that is still blank. A field of only whitespace
+// counts as blank.
+func (p ingestPolicy) apply(l *IngestLog) error {
+	fields := map[string]*string{"service": &l.Service, "level": &l.Level, "message": &l.Message}
+	for _, f := range ingestFields {
+		v := fields[f]
+		if strings.TrimSpace(*v) == "" {
+			*v = p.defaults[f]
+		}
+		if p.required[f] && strings.TrimSpace(*v) == "" {
+			return fmt.Errorf("%s is required", f)
+		}
+	}
+	return nil
+}
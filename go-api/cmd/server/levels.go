@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// logLevels are the canonical levels accepted on ingestion.
+var logLevels = map[string]bool{
+	"debug": true, "info": true, "warn": true, "warning": true,
+	"error": true, "critical": true, "fatal": true, "panic": true,
+}
+
+// defaultLevelAliases maps level names common in other logging stacks onto
+// the canonical ones. LEVEL_ALIASES adds to or overrides these.
+var defaultLevelAliases = map[string]string{
+	"trace":       "debug",
+	"dbg":         "debug",
+	"information": "info",
+	"notice":      "info",
+	"warning":     "warn",
+	"err":         "error",
+	"severe":      "error",
+	"crit":        "fatal",
+	"alert":       "critical",
+	"emerg":       "panic",
+	"emergency":   "panic",
+}
+
+// parseLevelAliases merges a JSON object of alias to canonical level, such
+// as {"wrn":"warn"}, over defaultLevelAliases. Keys are matched case
+// insensitively.
+func parseLevelAliases(raw string) (map[string]string, error) {
+	aliases := make(map[string]string, len(defaultLevelAliases))
+	for alias, level := range defaultLevelAliases {
+		aliases[alias] = level
+	}
+	if raw == "" {
+		return aliases, nil
+	}
+	var extra map[string]string
+	if err := json.Unmarshal([]byte(raw), &extra); err != nil {
+		return nil, fmt.Errorf("parse level aliases: %w", err)
+	}
+	for alias, level := range extra {
+		if !logLevels[level] {
+			return nil, fmt.Errorf("alias %q maps to unknown level %q", alias, level)
+		}
+		aliases[strings.ToLower(alias)] = level
+	}
+	return aliases, nil
+}
+
+// normalizeLevel lowercases a level and resolves it through the alias map.
+// Unknown levels are returned lowercased for validation to reject.
+func (h *Handler) normalizeLevel(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if canonical, ok := h.levelAliases[level]; ok {
+		return canonical
+	}
+	return level
+}
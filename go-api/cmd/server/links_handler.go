@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+type CreateIncidentLinkRequest struct {
+	Label string `json:"label" validate:"required,max=200"`
+	URL   string `json:"url" validate:"required,http_url,max=2000"`
+	Kind  string `json:"kind" validate:"omitempty,oneof=runbook dashboard ticket other"`
+}
+
+func (h *Handler) CreateIncidentLink(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	var req CreateIncidentLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.repo.GetIncident(ctx, id); err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+
+	link := &store.IncidentLink{
+		IncidentID: id,
+		Label:      req.Label,
+		URL:        req.URL,
+		Kind:       req.Kind,
+	}
+	if link.Kind == "" {
+		link.Kind = "other"
+	}
+	if err := h.repo.CreateIncidentLink(ctx, link); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to create link"})
+	}
+	return c.JSON(http.StatusCreated, link)
+}
+
+func (h *Handler) ListIncidentLinks(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	links, err := h.repo.ListIncidentLinks(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list links"})
+	}
+	return c.JSON(http.StatusOK, links)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const logSchemaHeader = "X-Log-Schema-Version"
+
+const (
+	schemaAccept = "accept"
+	schemaReject = "reject"
+)
+
+// logSchemas decides what POST /api/logs does with a batch that declares
+// its schema version in X-Log-Schema-Version: accept it as is, reject it,
+// or migrate it by renaming fields before it is bound. Versions not listed
+// get the unknown action. A batch without the header is always accepted.
+type logSchemas struct {
+	versions map[string]logSchema
+	unknown  string
+}
+
+// logSchema is one version's handling. Rename maps old field names to
+// current ones, at the batch level and within each log; renaming to ""
+// drops the field.
+type logSchema struct {
+	reject bool
+	rename map[string]string
+}
+
+// parseLogSchemas reads LOG_SCHEMA_VERSIONS, a JSON object mapping each
+// version to "accept", "reject" or a migration such as
+// {"rename":{"msg":"message"}}, and LOG_SCHEMA_UNKNOWN, the action for
+// other versions.
+func parseLogSchemas(raw, unknown string) (logSchemas, error) {
+	s := logSchemas{versions: map[string]logSchema{}, unknown: strings.ToLower(unknown)}
+	if s.unknown != schemaAccept && s.unknown != schemaReject {
+		return logSchemas{}, fmt.Errorf("unknown-version action must be %s or %s, got %q", schemaAccept, schemaReject, unknown)
+	}
+	if raw == "" {
+		return s, nil
+	}
+	var spec map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		return logSchemas{}, fmt.Errorf("parse schema versions: %w", err)
+	}
+	for version, v := range spec {
+		var action string
+		if err := json.Unmarshal(v, &action); err == nil {
+			switch strings.ToLower(action) {
+			case schemaAccept:
+				s.versions[version] = logSchema{}
+			case schemaReject:
+				s.versions[version] = logSchema{reject: true}
+			default:
+				return logSchemas{}, fmt.Errorf("version %s: action must be %s, %s or a migration, got %q", version, schemaAccept, schemaReject, action)
+			}
+			continue
+		}
+		var migration struct {
+			Rename map[string]string `json:"rename"`
+		}
+		if err := json.Unmarshal(v, &migration); err != nil {
+			return logSchemas{}, fmt.Errorf("version %s: %w", version, err)
+		}
+		if len(migration.Rename) == 0 {
+			return logSchemas{}, fmt.Errorf("version %s: migration renames nothing", version)
+		}
+		s.versions[version] = logSchema{rename: migration.Rename}
+	}
+	return s, nil
+}
+
+// prepare applies the policy for the request's declared schema version,
+// rewriting the body for a migrated version. A non-empty reason means the
+// batch is refused.
+func (s logSchemas) prepare(req *http.Request) (reason string, err error) {
+	version := strings.TrimSpace(req.Header.Get(logSchemaHeader))
+	if version == "" {
+		return "", nil
+	}
+	schema, ok := s.versions[version]
+	switch {
+	case !ok && s.unknown == schemaReject:
+		return fmt.Sprintf("unknown log schema version %q", version), nil
+	case !ok:
+		return "", nil
+	case schema.reject:
+		return fmt.Sprintf("log schema version %q is no longer accepted", version), nil
+	case len(schema.rename) == 0:
+		return "", nil
+	}
+
+	dec := json.NewDecoder(req.Body)
+	dec.UseNumber()
+	var batch map[string]any
+	if err := dec.Decode(&batch); err != nil {
+		return "", err
+	}
+	schema.migrate(batch)
+	if logs, ok := batch["logs"].([]any); ok {
+		for _, l := range logs {
+			if l, ok := l.(map[string]any); ok {
+				schema.migrate(l)
+			}
+		}
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	return "", nil
+}
+
+// migrate renames obj's fields in place. A renamed field never overwrites
+// one already present under the current name.
+func (s logSchema) migrate(obj map[string]any) {
+	for from, to := range s.rename {
+		v, ok := obj[from]
+		if !ok {
+			continue
+		}
+		delete(obj, from)
+		if _, taken := obj[to]; to != "" && !taken {
+			obj[to] = v
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+type CreateMaintenanceWindowRequest struct {
+	Service  *string   `json:"service" validate:"omitempty,max=200"`
+	StartsAt time.Time `json:"starts_at" validate:"required"`
+	EndsAt   time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+	Reason   string    `json:"reason" validate:"max=1000"`
+}
+
+func (h *Handler) CreateMaintenanceWindow(c echo.Context) error {
+	var req CreateMaintenanceWindowRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	w := &store.MaintenanceWindow{
+		Service:  req.Service,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Reason:   req.Reason,
+	}
+	if err := h.repo.CreateMaintenanceWindow(c.Request().Context(), w); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to create maintenance window"})
+	}
+	return c.JSON(http.StatusCreated, w)
+}
+
+// ListMaintenanceWindows returns all windows, or only current and upcoming
+// ones with ?active=true.
+func (h *Handler) ListMaintenanceWindows(c echo.Context) error {
+	var activeAt *time.Time
+	if active, _ := strconv.ParseBool(c.QueryParam("active")); active {
+		now := time.Now().UTC()
+		activeAt = &now
+	}
+
+	windows, err := h.repo.ListMaintenanceWindows(c.Request().Context(), activeAt)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list maintenance windows"})
+	}
+	return c.JSON(http.StatusOK, windows)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const (
+	metadataKeySample    = 1000
+	metadataKeyMaxSample = 10000
+)
+
+// valueCache memoizes the slowly changing distinct-value lists behind the
+// /api/meta endpoints, keyed by endpoint and raw ?since= value.
+type valueCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]valueCacheEntry
+}
+
+type valueCacheEntry struct {
+	values  []string
+	expires time.Time
+}
+
+func newValueCache(ttl time.Duration) *valueCache {
+	return &valueCache{ttl: ttl, entries: make(map[string]valueCacheEntry)}
+}
+
+func (vc *valueCache) get(key string, load func() ([]string, error)) ([]string, error) {
+	if vc.ttl <= 0 {
+		return load()
+	}
+	now := time.Now()
+	vc.mu.Lock()
+	entry, ok := vc.entries[key]
+	vc.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.values, nil
+	}
+
+	values, err := load()
+	if err != nil {
+		return nil, err
+	}
+	vc.mu.Lock()
+	for k, e := range vc.entries {
+		if !now.Before(e.expires) {
+			delete(vc.entries, k)
+		}
+	}
+	vc.entries[key] = valueCacheEntry{values: values, expires: now.Add(vc.ttl)}
+	vc.mu.Unlock()
+	return values, nil
+}
+
+// MetaServices lists the distinct services present in the logs, optionally
+// only those seen since ?since=.
+func (h *Handler) MetaServices(c echo.Context) error {
+	return h.distinctValues(c, "services", h.repo.DistinctLogServices)
+}
+
+// MetaLevels lists the distinct log levels present in the logs, optionally
+// only those seen since ?since=.
+func (h *Handler) MetaLevels(c echo.Context) error {
+	return h.distinctValues(c, "levels", h.repo.DistinctLogLevels)
+}
+
+// ServiceMetadataKeys samples the service's most recent logs (?sample=,
+// default 1000) and lists the top-level metadata keys they carry, with how
+// often each appears and the JSON types seen for it.
+func (h *Handler) ServiceMetadataKeys(c echo.Context) error {
+	service := c.Param("service")
+	sample, err := parseLimit(c.QueryParam("sample"), metadataKeySample, metadataKeyMaxSample)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	keys, sampled, err := h.repo.ServiceMetadataKeys(c.Request().Context(), service, sample)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list metadata keys"})
+	}
+	if keys == nil {
+		keys = []store.MetadataKey{}
+	}
+	return jsonWithETag(c, http.StatusOK, echo.Map{"service": service, "sampled": sampled, "keys": keys})
+}
+
+func (h *Handler) distinctValues(c echo.Context, kind string, query func(context.Context, *time.Time) ([]string, error)) error {
+	raw := c.QueryParam("since")
+	loc, err := requestTZ(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	var since *time.Time
+	if raw != "" {
+		t, err := parseSince(raw, 0, loc)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+		}
+		since = &t
+	}
+
+	ctx := c.Request().Context()
+	values, err := h.metaCache.get(kind+"|"+raw+"|"+loc.String(), func() ([]string, error) {
+		return query(ctx, since)
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list " + kind})
+	}
+	if values == nil {
+		values = []string{}
+	}
+	return jsonWithETag(c, http.StatusOK, values)
+}
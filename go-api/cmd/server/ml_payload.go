@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const (
+	mlTemplateLogLimit      = 50
+	mlTemplateIncidentLimit = 5
+)
+
+// mlRequestData is the value an ML request template is executed against.
+// Logs and PriorIncidents are methods so they are only queried when the
+// template actually references them.
+type mlRequestData struct {
+	Incident store.Incident
+
+	ctx  context.Context
+	repo store.Repository
+}
+
+// Logs returns the most recent logs for the incident's service, or across all
+// services when the incident has none.
+func (d *mlRequestData) Logs() ([]store.LogEntry, error) {
+	if d.Incident.Service == nil {
+		return d.repo.ListRecentLogs(d.ctx, mlTemplateLogLimit)
+	}
+	return d.repo.ListRecentServiceLogs(d.ctx, *d.Incident.Service, mlTemplateLogLimit)
+}
+
+// PriorIncidents returns earlier incidents for the same service.
+func (d *mlRequestData) PriorIncidents() ([]store.Incident, error) {
+	if d.Incident.Service == nil {
+		return nil, nil
+	}
+	incidents, err := d.repo.ListServiceIncidents(d.ctx, *d.Incident.Service, mlTemplateIncidentLimit+1, store.SortByCreated)
+	if err != nil {
+		return nil, err
+	}
+	prior := make([]store.Incident, 0, len(incidents))
+	for _, inc := range incidents {
+		if inc.ID != d.Incident.ID && len(prior) < mlTemplateIncidentLimit {
+			prior = append(prior, inc)
+		}
+	}
+	return prior, nil
+}
+
+var mlTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// loadMLRequestTemplate parses the template at path and checks that it renders
+// valid JSON for a sample incident, so mistakes surface at startup rather than
+// on the first analysis request.
+func loadMLRequestTemplate(path string) (*template.Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New("ml_request").Funcs(mlTemplateFuncs).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+
+	service := "sample-service"
+	sample := &mlRequestData{
+		Incident: store.Incident{
+			ID:          1,
+			CreatedAt:   time.Now().UTC(),
+			Status:      "open",
+			Severity:    "medium",
+			Description: "sample incident",
+			Service:     &service,
+		},
+		ctx:  context.Background(),
+		repo: sampleRepo{},
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, sample); err != nil {
+		return nil, fmt.Errorf("rendering sample: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("template does not render valid JSON: %s", buf.String())
+	}
+	return tmpl, nil
+}
+
+// buildMLRequest renders the analyze_incident payload, using the configured
+// template when present and the plain {incident_id, description} otherwise.
+func (h *Handler) buildMLRequest(ctx context.Context, incident *store.Incident) ([]byte, error) {
+	if h.mlTemplate == nil {
+		return json.Marshal(map[string]any{
+			"incident_id": incident.ID,
+			"description": incident.Description,
+		})
+	}
+
+	var buf bytes.Buffer
+	data := &mlRequestData{Incident: *incident, ctx: ctx, repo: h.repo}
+	if err := h.mlTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sampleRepo feeds canned data to the startup template check.
+type sampleRepo struct {
+	store.Repository
+}
+
+func (sampleRepo) ListRecentLogs(ctx context.Context, limit int) ([]store.LogEntry, error) {
+	return sampleRepo{}.ListRecentServiceLogs(ctx, "sample-service", limit)
+}
+
+func (sampleRepo) ListRecentServiceLogs(_ context.Context, service string, _ int) ([]store.LogEntry, error) {
+	return []store.LogEntry{{
+		ID:        1,
+		Timestamp: time.Now().UTC(),
+		Service:   service,
+		Level:     "error",
+		Message:   "sample log",
+		Metadata:  json.RawMessage(`{}`),
+	}}, nil
+}
+
+func (sampleRepo) ListServiceIncidents(context.Context, string, int, store.IncidentSort) ([]store.Incident, error) {
+	return nil, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	mlWarmupInitialBackoff = time.Second
+	mlWarmupMaxBackoff     = 30 * time.Second
+)
+
+// warmupML primes the ML service with a throwaway analysis request so the
+// first real summary doesn't pay its cold start. It retries with backoff
+// until the service answers or ctx is done, and is meant to run in its own
+// goroutine.
+func (h *Handler) warmupML(ctx context.Context) {
+	body, _ := json.Marshal(map[string]any{"incident_id": 0, "description": "warmup"})
+	url := fmt.Sprintf("%s/analyze_incident", h.mlService)
+	start := time.Now()
+
+	backoff := mlWarmupInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := h.mlWarmupRequest(ctx, url, body)
+		if err == nil {
+			log.Printf("ml warmup: service ready after %d attempt(s) in %s", attempt, time.Since(start).Round(time.Millisecond))
+			return
+		}
+		log.Printf("ml warmup: attempt %d: %v (retrying in %s)", attempt, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, mlWarmupMaxBackoff)
+	}
+}
+
+func (h *Handler) mlWarmupRequest(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// outboundTLS configures certificate checks for one outbound target.
+type outboundTLS struct {
+	caFile     string
+	skipVerify bool
+}
+
+// newOutboundClient builds an HTTP client for calls leaving the service. It
+// goes through OUTBOUND_PROXY when set and otherwise honors the standard
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables.
+func newOutboundClient(cfg Config, timeout time.Duration, tlsOpts outboundTLS) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if cfg.OutboundProxy != "" {
+		proxyURL, err := url.Parse(cfg.OutboundProxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OUTBOUND_PROXY: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if tlsOpts.caFile != "" || tlsOpts.skipVerify {
+		tlsConfig := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: tlsOpts.skipVerify,
+		}
+		if tlsOpts.caFile != "" {
+			pem, err := os.ReadFile(tlsOpts.caFile)
+			if err != nil {
+				return nil, fmt.Errorf("read CA file: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", tlsOpts.caFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	// The runtime image has no zoneinfo; embed it so ?tz= works there.
+	_ "time/tzdata"
+
+	"github.com/labstack/echo/v4"
+)
+
+// naiveLayouts are the since/until forms without a UTC offset; they are
+// read in the request's ?tz=.
+var naiveLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// requestTZ parses ?tz=, an IANA zone name such as "America/New_York".
+// Without one, naive dates are UTC.
+func requestTZ(c echo.Context) (*time.Location, error) {
+	raw := c.QueryParam("tz")
+	if raw == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tz %q: use an IANA zone name like Europe/Berlin", raw)
+	}
+	return loc, nil
+}
+
+// parseTime accepts an RFC3339 timestamp, or a date or date-time without an
+// offset, which is taken in loc. The result is always UTC.
+func parseTime(raw string, loc *time.Location) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), true
+	}
+	for _, layout := range naiveLayouts {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseSince accepts either a timestamp as parseTime does or a Go duration
+// such as "24h", which is taken relative to now. An empty value falls back
+// to now minus def.
+func parseSince(raw string, def time.Duration, loc *time.Location) (time.Time, error) {
+	now := time.Now().UTC()
+	if raw == "" {
+		return now.Add(-def), nil
+	}
+	if t, ok := parseTime(raw, loc); ok {
+		return t, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("invalid since %q: use RFC3339, a date like 2006-01-02 or a positive duration like 24h", raw)
+	}
+	return now.Add(-d), nil
+}
+
+// parseUntil accepts a timestamp as parseTime does; an empty value means
+// now. A bare date is the start of that day, so until is exclusive of it.
+func parseUntil(raw string, loc *time.Location) (time.Time, error) {
+	if raw == "" {
+		return time.Now().UTC(), nil
+	}
+	t, ok := parseTime(raw, loc)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid until %q: use RFC3339 or a date like 2006-01-02", raw)
+	}
+	return t, nil
+}
+
+// pageSize is the default and maximum ?limit= shared by the paginated list
+// endpoints.
+type pageSize struct {
+	Default int
+	Max     int
+}
+
+func (p pageSize) validate() error {
+	if p.Default <= 0 || p.Max < p.Default {
+		return fmt.Errorf("default %d must be positive and no larger than max %d", p.Default, p.Max)
+	}
+	return nil
+}
+
+// parse parses a list endpoint's ?limit= value.
+func (p pageSize) parse(raw string) (int, error) {
+	return parseLimit(raw, p.Default, p.Max)
+}
+
+// parseLimit parses a ?limit= value, defaulting to def and capping at max.
+func parseLimit(raw string, def, max int) (int, error) {
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid limit %q", raw)
+	}
+	if n > max {
+		n = max
+	}
+	return n, nil
+}
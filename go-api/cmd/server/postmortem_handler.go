@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const (
+	postmortemLogLead  = 15 * time.Minute
+	postmortemLogLimit = 50
+)
+
+// IncidentPostmortem renders a Markdown postmortem draft pre-filled with the
+// incident's analysis, timeline, service logs and time to resolve.
+func (h *Handler) IncidentPostmortem(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	ctx := c.Request().Context()
+	incident, err := h.repo.GetIncident(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+	events, err := h.repo.ListIncidentEvents(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load incident events"})
+	}
+	logs, err := h.postmortemLogs(ctx, incident)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load incident logs"})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="incident-%d-postmortem.md"`, id))
+	return c.Blob(http.StatusOK, "text/markdown; charset=utf-8", []byte(renderPostmortem(incident, events, logs)))
+}
+
+// postmortemLogs returns the service's logs from shortly before the incident
+// opened until it was resolved.
+func (h *Handler) postmortemLogs(ctx context.Context, inc *store.Incident) ([]store.LogEntry, error) {
+	if inc.Service == nil {
+		return nil, nil
+	}
+	since := inc.CreatedAt.Add(-postmortemLogLead)
+	return h.repo.ListLogs(ctx, store.LogQuery{
+		Service:   *inc.Service,
+		Since:     &since,
+		Until:     inc.ResolvedAt,
+		Ascending: true,
+		Limit:     postmortemLogLimit,
+	})
+}
+
+func renderPostmortem(inc *store.Incident, events []store.IncidentEvent, logs []store.LogEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Postmortem: Incident #%d\n\n", inc.ID)
+
+	b.WriteString("## Overview\n\n")
+	fmt.Fprintf(&b, "- **Severity:** %s\n", inc.Severity)
+	fmt.Fprintf(&b, "- **Status:** %s\n", inc.Status)
+	if inc.Service != nil {
+		fmt.Fprintf(&b, "- **Service:** %s\n", *inc.Service)
+	}
+	fmt.Fprintf(&b, "- **Opened:** %s\n", inc.CreatedAt.UTC().Format(time.RFC3339))
+	if inc.ResolvedAt != nil {
+		fmt.Fprintf(&b, "- **Resolved:** %s\n", inc.ResolvedAt.UTC().Format(time.RFC3339))
+		fmt.Fprintf(&b, "- **Time to resolve:** %s\n", inc.ResolvedAt.Sub(inc.CreatedAt).Round(time.Second))
+	} else {
+		b.WriteString("- **Time to resolve:** unresolved\n")
+	}
+	if len(inc.Tags) > 0 {
+		fmt.Fprintf(&b, "- **Tags:** %s\n", strings.Join(inc.Tags, ", "))
+	}
+	fmt.Fprintf(&b, "\n%s\n\n", inc.Description)
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString(orPlaceholder(inc.Summary))
+	b.WriteString("\n\n## Root Cause\n\n")
+	b.WriteString(orPlaceholder(inc.RootCause))
+
+	b.WriteString("\n\n## Timeline\n\n")
+	fmt.Fprintf(&b, "- %s — incident opened\n", inc.CreatedAt.UTC().Format(time.RFC3339))
+	for _, ev := range events {
+		line := ev.Kind
+		if ev.Message != "" {
+			line += ": " + ev.Message
+		}
+		fmt.Fprintf(&b, "- %s — %s (%s)\n", ev.CreatedAt.UTC().Format(time.RFC3339), line, ev.Actor)
+	}
+
+	b.WriteString("\n## Logs\n\n")
+	if len(logs) == 0 {
+		b.WriteString("_No service logs recorded for this incident._\n")
+	} else {
+		b.WriteString("```\n")
+		for _, l := range logs {
+			fmt.Fprintf(&b, "%s [%s] %s: %s\n", l.Timestamp.UTC().Format(time.RFC3339), l.Level, l.Service, l.Message)
+		}
+		b.WriteString("```\n")
+	}
+
+	b.WriteString("\n## Impact\n\n_TODO_\n\n## Action Items\n\n- [ ] _TODO_\n")
+	return b.String()
+}
+
+func orPlaceholder(s *string) string {
+	if s == nil || *s == "" {
+		return "_TODO_"
+	}
+	return *s
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const queueScanLimit = 500
+
+var severityWeight = map[string]float64{
+	"critical": 4,
+	"high":     3,
+	"medium":   2,
+	"low":      1,
+}
+
+type queueItem struct {
+	store.Incident
+	Priority float64 `json:"priority"`
+}
+
+// incidentPriority ranks severity first, then age (capped at a day) and how
+// often the incident has recurred (capped at 50 occurrences).
+func incidentPriority(inc store.Incident, now time.Time) float64 {
+	ageHours := math.Min(now.Sub(inc.CreatedAt).Hours(), 24)
+	occurrences := math.Min(float64(inc.OccurrenceCount), 50)
+	score := severityWeight[inc.Severity]*100 + ageHours*2 + occurrences
+	if inc.Status == "acknowledged" {
+		score -= 50
+	}
+	return math.Round(score*100) / 100
+}
+
+// IncidentQueue is the on-call "what next" feed: unresolved incidents sorted
+// by priority. Snoozed incidents are left out, and so are those the caller
+// has already acknowledged when they identify themselves via X-User.
+func (h *Handler) IncidentQueue(c echo.Context) error {
+	limit, err := h.pageSize.parse(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	incidents, err := h.repo.ListUnresolvedIncidents(c.Request().Context(), max(queueScanLimit, limit))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load incident queue"})
+	}
+
+	me := requestUser(c)
+	now := time.Now()
+	h.slaTargets.Apply(incidents, now)
+	h.severityDisplay.apply(incidents)
+	queue := make([]queueItem, 0, len(incidents))
+	for _, inc := range incidents {
+		if inc.SnoozedUntil != nil && inc.SnoozedUntil.After(now) {
+			continue
+		}
+		if me != "" && inc.Status == "acknowledged" && inc.AcknowledgedBy != nil && *inc.AcknowledgedBy == me {
+			continue
+		}
+		queue = append(queue, queueItem{Incident: inc, Priority: incidentPriority(inc, now)})
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		return queue[i].Priority > queue[j].Priority
+	})
+	total := int64(len(queue))
+	if len(queue) > limit {
+		queue = queue[:limit]
+	}
+	return respondList(c, queue, listMeta{Count: len(queue), Total: &total})
+}
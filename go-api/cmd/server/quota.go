@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"Incident_Monitoring_Project/internal/metrics"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// quotaSweepAt is how many services' buckets are kept before idle ones are
+// swept, bounding memory when service names churn.
+const quotaSweepAt = 10000
+
+// ingestQuotas caps how many logs per second each service may ingest, so a
+// single noisy service cannot use up the ingestion budget of the others.
+// Each service has a token bucket refilled at its rate and holding one
+// second's worth.
+type ingestQuotas struct {
+	def     float64
+	rates   map[string]float64
+	metrics *metrics.Registry
+
+	mu      sync.Mutex
+	buckets map[string]*quotaBucket
+}
+
+type quotaBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// parseIngestQuotas combines INGEST_QUOTA_DEFAULT, the logs/sec allowed to
+// any service, with INGEST_QUOTAS, a JSON object of per-service overrides
+// such as {"checkout":500}. A rate of 0 means unlimited. It returns nil when
+// no service is limited.
+func parseIngestQuotas(def int64, raw string, reg *metrics.Registry) (*ingestQuotas, error) {
+	if def < 0 {
+		return nil, fmt.Errorf("default quota must not be negative")
+	}
+	q := &ingestQuotas{def: float64(def), rates: map[string]float64{}, metrics: reg, buckets: map[string]*quotaBucket{}}
+	if raw != "" {
+		var spec map[string]int64
+		if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+			return nil, fmt.Errorf("parse quotas: %w", err)
+		}
+		for service, rate := range spec {
+			if rate < 0 {
+				return nil, fmt.Errorf("service %s: quota must not be negative", service)
+			}
+			q.rates[service] = float64(rate)
+		}
+	}
+	limited := def > 0
+	for _, rate := range q.rates {
+		limited = limited || rate > 0
+	}
+	if !limited {
+		return nil, nil
+	}
+	return q, nil
+}
+
+// allow spends one of service's tokens, reporting false, and counting the
+// drop in ingest_quota_dropped_total, when it has none left.
+func (q *ingestQuotas) allow(service string, now time.Time) bool {
+	if q == nil {
+		return true
+	}
+	rate, ok := q.rates[service]
+	if !ok {
+		rate = q.def
+	}
+	if rate <= 0 {
+		return true
+	}
+
+	q.mu.Lock()
+	b, ok := q.buckets[service]
+	if !ok {
+		if len(q.buckets) >= quotaSweepAt {
+			q.sweep(now)
+		}
+		b = &quotaBucket{tokens: rate, last: now}
+		q.buckets[service] = b
+	}
+	b.tokens = min(rate, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	q.mu.Unlock()
+
+	if !allowed {
+		q.metrics.Counter("ingest_quota_dropped_total", "Logs dropped for exceeding their service's ingestion quota.", "service", service).Inc()
+	}
+	return allowed
+}
+
+// applyQuotas drops the logs whose services are over quota, keeping
+// positions in step, and counts the drops by service; dropped is nil when
+// nothing was dropped.
+func (h *Handler) applyQuotas(logs []store.LogEntry, positions []int, now time.Time) (kept []store.LogEntry, keptPositions []int, dropped map[string]int) {
+	if h.quotas == nil {
+		return logs, positions, nil
+	}
+	kept, keptPositions = logs[:0], positions[:0]
+	for i, l := range logs {
+		if !h.quotas.allow(l.Service, now) {
+			if dropped == nil {
+				dropped = make(map[string]int)
+			}
+			dropped[l.Service]++
+			continue
+		}
+		kept = append(kept, l)
+		keptPositions = append(keptPositions, positions[i])
+	}
+	return kept, keptPositions, dropped
+}
+
+// sweep forgets buckets that have been idle long enough to have refilled;
+// a new bucket starts full, so nothing changes for them. q.mu must be held.
+func (q *ingestQuotas) sweep(now time.Time) {
+	for service, b := range q.buckets {
+		if now.Sub(b.last) > time.Second {
+			delete(q.buckets, service)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/jobs"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// reanalyzeMaxIncidents bounds how many active incidents one reanalysis
+// job covers.
+const reanalyzeMaxIncidents = 5000
+
+// ReanalyzeOpenIncidents re-runs the ML analysis of every open and
+// acknowledged incident as a background job, at most
+// ML_REANALYZE_CONCURRENCY at a time, and answers 202 with the job to poll.
+// It answers 409 while a previous reanalysis is unfinished.
+func (h *Handler) ReanalyzeOpenIncidents(c echo.Context) error {
+	ctx := c.Request().Context()
+	incidents, err := h.repo.ListUnresolvedIncidents(ctx, reanalyzeMaxIncidents)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list incidents"})
+	}
+
+	job, err := h.jobs.Submit(ctx, "reanalyze-open", true, func(ctx context.Context, p *jobs.Progress) (any, error) {
+		return nil, h.reanalyze(ctx, p, incidents)
+	})
+	if errors.Is(err, jobs.ErrKindRunning) {
+		return c.JSON(http.StatusConflict, echo.Map{"error": "a reanalysis is already running"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to start job"})
+	}
+	c.Response().Header().Set(echo.HeaderLocation, "/api/admin/jobs/"+job.ID)
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// reanalyze analyzes incidents through a pool of mlConcurrency workers,
+// saving each new analysis. It stops handing out incidents once ctx is
+// canceled, leaving analyses already underway to finish.
+func (h *Handler) reanalyze(ctx context.Context, p *jobs.Progress, incidents []store.Incident) error {
+	p.SetTotal(len(incidents))
+	work := make(chan *store.Incident)
+	var wg sync.WaitGroup
+	for range max(h.mlConcurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for inc := range work {
+				if reason := h.reanalyzeIncident(context.WithoutCancel(ctx), inc); reason != "" {
+					p.Fail(fmt.Sprintf("incident %d", inc.ID), reason)
+				} else {
+					p.Succeed()
+				}
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for i := range incidents {
+		select {
+		case work <- &incidents[i]:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	return err
+}
+
+// reanalyzeIncident analyzes and saves one incident, returning why it
+// failed, if it did.
+func (h *Handler) reanalyzeIncident(ctx context.Context, inc *store.Incident) string {
+	summary, rootCause, reason, err := h.analyze(ctx, inc)
+	if err != nil {
+		return err.Error()
+	}
+	if reason != "" {
+		return reason
+	}
+	if err := h.repo.UpdateIncidentSummary(ctx, inc.ID, summary, rootCause); err != nil {
+		return "failed to save summary"
+	}
+	return ""
+}
+
+// GetJob reports a background job's status, progress and result.
+func (h *Handler) GetJob(c echo.Context) error {
+	job, err := h.jobs.Get(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "job not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load job"})
+	}
+	return c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// CreateIncidentRelationRequest links the path incident, as parent, to
+// ChildID, e.g. a database incident that caused an API incident.
+type CreateIncidentRelationRequest struct {
+	ChildID      int64  `json:"child_id" validate:"required,gt=0"`
+	RelationType string `json:"relation_type" validate:"omitempty,oneof=caused related duplicate"`
+}
+
+func (h *Handler) CreateIncidentRelation(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	var req CreateIncidentRelationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+	if req.ChildID == id {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "an incident cannot be related to itself"})
+	}
+
+	rel := &store.IncidentRelation{ParentID: id, ChildID: req.ChildID, RelationType: req.RelationType}
+	if rel.RelationType == "" {
+		rel.RelationType = "related"
+	}
+	err = h.repo.CreateIncidentRelation(c.Request().Context(), rel)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	case errors.Is(err, store.ErrConflict):
+		return c.JSON(http.StatusConflict, echo.Map{"error": "incidents are already related"})
+	case errors.Is(err, store.ErrCycle):
+		return c.JSON(http.StatusConflict, echo.Map{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to relate incidents"})
+	}
+	return c.JSON(http.StatusCreated, rel)
+}
+
+func (h *Handler) DeleteIncidentRelation(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+	childID, err := strconv.ParseInt(c.Param("child_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid child incident id"})
+	}
+
+	err = h.repo.DeleteIncidentRelation(c.Request().Context(), id, childID)
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "relation not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to remove relation"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) ListRelatedIncidents(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	related, err := h.repo.ListRelatedIncidents(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list related incidents"})
+	}
+	return c.JSON(http.StatusOK, related)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// IncidentReport renders a one-document PDF of the incident for stakeholders:
+// overview, time to resolve, summary, root cause and timeline.
+func (h *Handler) IncidentReport(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	ctx := c.Request().Context()
+	incident, err := h.repo.GetIncident(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+	events, err := h.repo.ListIncidentEvents(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load incident events"})
+	}
+
+	pdf, err := renderIncidentReport(incident, events)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to render report"})
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="incident-%d-report.pdf"`, id))
+	return c.Blob(http.StatusOK, "application/pdf", pdf)
+}
+
+func renderIncidentReport(inc *store.Incident, events []store.IncidentEvent) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.SetTitle(fmt.Sprintf("Incident #%d", inc.ID), true)
+	// The footer sits inside the bottom margin, so it is drawn by the
+	// footer hook rather than in the flow, where it would force a new page.
+	generated := "Generated " + time.Now().UTC().Format(time.RFC3339)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, generated, "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+	// The core fonts are cp1252; translate so descriptions and summaries
+	// with accents or dashes come out intact.
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	heading := func(s string) {
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "B", 13)
+		pdf.CellFormat(0, 8, tr(s), "B", 1, "L", false, 0, "")
+		pdf.Ln(2)
+	}
+	field := func(label, value string) {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(40, 6, tr(label), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(0, 6, tr(value), "", "L", false)
+	}
+	para := func(s *string) {
+		pdf.SetFont("Helvetica", "", 10)
+		if s == nil || *s == "" {
+			pdf.SetTextColor(120, 120, 120)
+			pdf.MultiCell(0, 5, "Not available.", "", "L", false)
+			pdf.SetTextColor(0, 0, 0)
+			return
+		}
+		pdf.MultiCell(0, 5, tr(*s), "", "L", false)
+	}
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Incident #%d", inc.ID), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.MultiCell(0, 6, tr(inc.Description), "", "L", false)
+
+	heading("Overview")
+	service := "unattributed"
+	if inc.Service != nil {
+		service = *inc.Service
+	}
+	field("Service", service)
+	field("Severity", inc.Severity)
+	field("Status", inc.Status)
+	field("Opened", inc.CreatedAt.UTC().Format(time.RFC3339))
+	if inc.AcknowledgedAt != nil {
+		field("Acknowledged", inc.AcknowledgedAt.UTC().Format(time.RFC3339))
+	}
+	if inc.ResolvedAt != nil {
+		field("Resolved", inc.ResolvedAt.UTC().Format(time.RFC3339))
+		field("Time to resolve", inc.ResolvedAt.Sub(inc.CreatedAt).Round(time.Second).String())
+	} else {
+		field("Time to resolve", "unresolved")
+	}
+	if len(inc.Tags) > 0 {
+		field("Tags", strings.Join(inc.Tags, ", "))
+	}
+
+	heading("Summary")
+	para(inc.Summary)
+	heading("Root Cause")
+	para(inc.RootCause)
+
+	heading("Timeline")
+	pdf.SetFont("Helvetica", "", 9)
+	entry := func(at time.Time, text string) {
+		pdf.CellFormat(45, 5, at.UTC().Format("2006-01-02 15:04:05Z"), "", 0, "L", false, 0, "")
+		pdf.MultiCell(0, 5, tr(text), "", "L", false)
+	}
+	entry(inc.CreatedAt, "incident opened")
+	for _, ev := range events {
+		line := ev.Kind
+		if ev.Message != "" {
+			line += ": " + ev.Message
+		}
+		entry(ev.CreatedAt, fmt.Sprintf("%s (%s)", line, ev.Actor))
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// requestLogLevel orders request log lines. A request is logged at its
+// route's level, raised to warn for a 4xx and error for a 5xx, and only
+// written when that reaches REQUEST_LOG_LEVEL.
+type requestLogLevel int
+
+const (
+	logDebug requestLogLevel = iota
+	logInfo
+	logWarn
+	logError
+	// logOff, as a route's level, drops its requests whatever their status.
+	logOff
+)
+
+var requestLogLevelNames = [...]string{"debug", "info", "warn", "error", "off"}
+
+func (l requestLogLevel) String() string { return requestLogLevelNames[l] }
+
+func parseRequestLogLevel(raw string) (requestLogLevel, error) {
+	name := strings.ToLower(strings.TrimSpace(raw))
+	for level, n := range requestLogLevelNames {
+		if n == name {
+			return requestLogLevel(level), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown level %q: use debug, info, warn, error or off", raw)
+}
+
+// defaultRequestLogRoutes keeps orchestrator probes and scrapes out of the
+// log unless they fail; REQUEST_LOG_ROUTES is merged over it.
+var defaultRequestLogRoutes = map[string]string{
+	"/api/health*": "debug",
+	"/metrics":     "debug",
+}
+
+// requestLogRoutes maps routes to their level. A pattern ending in * is a
+// prefix of the request path; any other pattern is an exact route, such as
+// /api/incidents/:incident_id, or request path.
+type requestLogRoutes struct {
+	exact    map[string]requestLogLevel
+	prefixes []string
+	byPrefix map[string]requestLogLevel
+}
+
+// parseRequestLogRoutes reads REQUEST_LOG_ROUTES, a JSON object of route
+// patterns to levels such as {"/api/health*":"off"}.
+func parseRequestLogRoutes(raw string) (*requestLogRoutes, error) {
+	spec := make(map[string]string, len(defaultRequestLogRoutes))
+	for pattern, level := range defaultRequestLogRoutes {
+		spec[pattern] = level
+	}
+	if raw != "" {
+		var custom map[string]string
+		if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+			return nil, fmt.Errorf("parse route levels: %w", err)
+		}
+		for pattern, level := range custom {
+			spec[pattern] = level
+		}
+	}
+
+	r := &requestLogRoutes{exact: make(map[string]requestLogLevel), byPrefix: make(map[string]requestLogLevel)}
+	for pattern, raw := range spec {
+		level, err := parseRequestLogLevel(raw)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", pattern, err)
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			r.prefixes = append(r.prefixes, prefix)
+			r.byPrefix[prefix] = level
+			continue
+		}
+		r.exact[pattern] = level
+	}
+	return r, nil
+}
+
+// level is the configured level for a request, preferring an exact match
+// and then the longest matching prefix.
+func (r *requestLogRoutes) level(route, path string) requestLogLevel {
+	if level, ok := r.exact[route]; ok {
+		return level
+	}
+	if level, ok := r.exact[path]; ok {
+		return level
+	}
+	best, level := -1, logInfo
+	for _, prefix := range r.prefixes {
+		if len(prefix) > best && strings.HasPrefix(path, prefix) {
+			best, level = len(prefix), r.byPrefix[prefix]
+		}
+	}
+	return level
+}
+
+// requestLogLine is one request's JSON log line, with the fields echo's
+// default logger writes plus its level.
+type requestLogLine struct {
+	Time         string `json:"time"`
+	Level        string `json:"level"`
+	ID           string `json:"id"`
+	RemoteIP     string `json:"remote_ip"`
+	Host         string `json:"host"`
+	Method       string `json:"method"`
+	URI          string `json:"uri"`
+	UserAgent    string `json:"user_agent"`
+	Status       int    `json:"status"`
+	Error        string `json:"error"`
+	Latency      int64  `json:"latency"`
+	LatencyHuman string `json:"latency_human"`
+	BytesIn      int64  `json:"bytes_in"`
+	BytesOut     int64  `json:"bytes_out"`
+}
+
+// requestLogMiddleware logs requests as JSON lines to out, at the level
+// routes give them, leaving out those below min.
+func requestLogMiddleware(out io.Writer, min requestLogLevel, routes *requestLogRoutes) echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		HandleError:      true,
+		LogLatency:       true,
+		LogRemoteIP:      true,
+		LogHost:          true,
+		LogMethod:        true,
+		LogURI:           true,
+		LogURIPath:       true,
+		LogRoutePath:     true,
+		LogRequestID:     true,
+		LogUserAgent:     true,
+		LogStatus:        true,
+		LogError:         true,
+		LogContentLength: true,
+		LogResponseSize:  true,
+		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			level := routes.level(v.RoutePath, v.URIPath)
+			if level == logOff {
+				return nil
+			}
+			switch {
+			case v.Status >= http.StatusInternalServerError:
+				level = max(level, logError)
+			case v.Status >= http.StatusBadRequest:
+				level = max(level, logWarn)
+			}
+			if level < min {
+				return nil
+			}
+
+			line := requestLogLine{
+				Time:         v.StartTime.Format(time.RFC3339Nano),
+				Level:        level.String(),
+				ID:           v.RequestID,
+				RemoteIP:     v.RemoteIP,
+				Host:         v.Host,
+				Method:       v.Method,
+				URI:          v.URI,
+				UserAgent:    v.UserAgent,
+				Status:       v.Status,
+				Latency:      v.Latency.Nanoseconds(),
+				LatencyHuman: v.Latency.String(),
+				BytesOut:     v.ResponseSize,
+			}
+			if v.Error != nil {
+				line.Error = v.Error.Error()
+			}
+			line.BytesIn, _ = strconv.ParseInt(v.ContentLength, 10, 64)
+			b, err := json.Marshal(line)
+			if err != nil {
+				return err
+			}
+			_, err = out.Write(append(b, '\n'))
+			return err
+		},
+	})
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// keepDebugLog reports whether a debug log survives 1-in-rate sampling. The
+// decision is a hash of the trace_id metadata (or service and message when no
+// trace is present), so related logs are kept or dropped together.
+func keepDebugLog(l IngestLog, rate int) bool {
+	if rate <= 1 || l.Level != "debug" {
+		return true
+	}
+	h := fnv.New32a()
+	if traceID, ok := l.Metadata["trace_id"]; ok {
+		fmt.Fprint(h, traceID)
+	} else {
+		h.Write([]byte(l.Service))
+		h.Write([]byte{0})
+		h.Write([]byte(l.Message))
+	}
+	return h.Sum32()%uint32(rate) == 0
+}
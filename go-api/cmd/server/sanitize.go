@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sanitizeText prepares free text from clients for storage, rendering and
+// ML prompts: line endings become \n, invalid UTF-8 and control characters
+// other than newlines and tabs are dropped, and surrounding whitespace is
+// trimmed.
+func sanitizeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ToValidUTF8(s, "")
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+// cleanText sanitizes a required text field and checks it is not empty and
+// holds at most max characters.
+func cleanText(field, s string, max int) (string, error) {
+	s = sanitizeText(s)
+	if s == "" {
+		return "", fmt.Errorf("%s must not be empty", field)
+	}
+	if n := utf8.RuneCountInString(s); n > max {
+		return "", fmt.Errorf("%s is %d characters, over the %d limit", field, n, max)
+	}
+	return s, nil
+}
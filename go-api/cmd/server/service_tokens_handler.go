@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/auth"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const (
+	ingestTokenHeader  = "X-Ingest-Token"
+	serviceTokenPrefix = "ist_"
+)
+
+type CreateServiceTokenRequest struct {
+	Service string `json:"service" validate:"required,max=200"`
+}
+
+// isIngestRoute reports whether the request is one of the log ingestion
+// endpoints that accept a service token.
+func isIngestRoute(c echo.Context) bool {
+	if c.Request().Method != http.MethodPost {
+		return false
+	}
+	switch c.Path() {
+	case "/api/logs", "/api/logs/upload":
+		return true
+	}
+	return false
+}
+
+func hashServiceToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ingestTokenMiddleware resolves the service token presented in
+// X-Ingest-Token and restricts the request to ingesting logs for that
+// token's service. Without a token the request passes through unrestricted,
+// unless tokens are required.
+func ingestTokenMiddleware(repo store.Repository, required bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			presented := strings.TrimSpace(req.Header.Get(ingestTokenHeader))
+			if presented == "" {
+				if required {
+					return c.JSON(http.StatusUnauthorized, echo.Map{"error": "ingestion token required"})
+				}
+				return next(c)
+			}
+
+			token, err := repo.LookupServiceToken(req.Context(), hashServiceToken(presented))
+			if errors.Is(err, store.ErrNotFound) {
+				return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid or revoked ingestion token"})
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to verify ingestion token"})
+			}
+			ctx := auth.WithIngestService(req.Context(), token.Service)
+			if _, ok := auth.Principal(ctx); !ok {
+				ctx = auth.WithPrincipal(ctx, "service:"+token.Service)
+			}
+			c.SetRequest(req.WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// checkIngestService fills in the token's service on a log that names none
+// and rejects one that names a different service.
+func checkIngestService(ctx context.Context, l *IngestLog) error {
+	service, ok := auth.IngestService(ctx)
+	if !ok {
+		return nil
+	}
+	if l.Service == "" {
+		l.Service = service
+	}
+	if l.Service != service {
+		return fmt.Errorf("service %q does not match the ingestion token's service %q", l.Service, service)
+	}
+	return nil
+}
+
+// CreateServiceToken mints an ingestion token for a service. The token is
+// only ever returned here; afterwards just its prefix is shown.
+func (h *Handler) CreateServiceToken(c echo.Context) error {
+	var req CreateServiceTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to generate token"})
+	}
+	raw := serviceTokenPrefix + hex.EncodeToString(secret)
+	t := &store.ServiceToken{
+		Service:   req.Service,
+		Prefix:    raw[:len(serviceTokenPrefix)+8],
+		CreatedBy: auth.Actor(c.Request().Context()),
+	}
+	if err := h.repo.CreateServiceToken(c.Request().Context(), t, hashServiceToken(raw)); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to create token"})
+	}
+	return c.JSON(http.StatusCreated, echo.Map{"token": raw, "service_token": t})
+}
+
+func (h *Handler) ListServiceTokens(c echo.Context) error {
+	tokens, err := h.repo.ListServiceTokens(c.Request().Context(), c.QueryParam("service"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list tokens"})
+	}
+	if tokens == nil {
+		tokens = []store.ServiceToken{}
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+func (h *Handler) RevokeServiceToken(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid token id"})
+	}
+	err = h.repo.RevokeServiceToken(c.Request().Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "token not found or already revoked"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to revoke token"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
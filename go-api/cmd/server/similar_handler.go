@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const (
+	similarDefaultScore = 0.3
+	similarDefaultLimit = 10
+	similarMaxLimit     = 50
+)
+
+// incidentResolution is how a similar past incident was resolved.
+type incidentResolution struct {
+	ResolvedAt    *time.Time `json:"resolved_at"`
+	TimeToResolve string     `json:"time_to_resolve,omitempty"`
+	Summary       *string    `json:"summary"`
+	RootCause     *string    `json:"root_cause"`
+}
+
+type similarIncident struct {
+	store.SimilarIncident
+	Resolution incidentResolution `json:"resolution"`
+}
+
+// SimilarIncidents lists resolved incidents whose descriptions resemble
+// this one's by trigram similarity, best match first, with how each was
+// resolved. ?min_score= (0 to 1, default 0.3) sets the cutoff.
+func (h *Handler) SimilarIncidents(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+	limit, err := parseLimit(c.QueryParam("limit"), similarDefaultLimit, similarMaxLimit)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	minScore := similarDefaultScore
+	if raw := c.QueryParam("min_score"); raw != "" {
+		if minScore, err = strconv.ParseFloat(raw, 64); err != nil || minScore < 0 || minScore > 1 {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("invalid min_score %q: use a number from 0 to 1", raw)})
+		}
+	}
+
+	similar, err := h.repo.SimilarIncidents(c.Request().Context(), id, limit, minScore)
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to find similar incidents"})
+	}
+
+	matches := make([]similarIncident, 0, len(similar))
+	for _, s := range similar {
+		res := incidentResolution{ResolvedAt: s.ResolvedAt, Summary: s.Summary, RootCause: s.RootCause}
+		if s.ResolvedAt != nil {
+			res.TimeToResolve = s.ResolvedAt.Sub(s.CreatedAt).Round(time.Second).String()
+		}
+		matches = append(matches, similarIncident{SimilarIncident: s, Resolution: res})
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"incident_id": id,
+		"min_score":   minScore,
+		"incidents":   matches,
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/auth"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const snoozeMaxDuration = 7 * 24 * time.Hour
+
+type SnoozeIncidentRequest struct {
+	Duration string `json:"duration" validate:"required"`
+	Reason   string `json:"reason" validate:"max=500"`
+}
+
+// SnoozeIncident silences an unresolved incident for a duration such as
+// "2h": it drops out of the on-call queue and ack reminders until the snooze
+// runs out or is lifted. Snoozing again replaces the previous snooze.
+func (h *Handler) SnoozeIncident(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+	var req SnoozeIncidentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil || d <= 0 || d > snoozeMaxDuration {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("invalid duration %q: use a positive duration up to %s, like 2h", req.Duration, snoozeMaxDuration)})
+	}
+
+	until := time.Now().UTC().Add(d).Truncate(time.Second)
+	message := "until " + until.Format(time.RFC3339)
+	if reason := strings.TrimSpace(req.Reason); reason != "" {
+		message += ": " + reason
+	}
+	ctx := c.Request().Context()
+	version, err := h.repo.SnoozeIncident(ctx, id, until, auth.Actor(ctx), message)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	case errors.Is(err, store.ErrConflict):
+		return c.JSON(http.StatusConflict, echo.Map{"error": "resolved incidents cannot be snoozed"})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to snooze incident"})
+	}
+
+	h.notifyWatchers(id, fmt.Sprintf("Incident #%d was snoozed", id), "Snoozed "+message)
+
+	c.Response().Header().Set("ETag", versionETag(version))
+	return c.JSON(http.StatusOK, echo.Map{"status": "snoozed", "snoozed_until": until, "version": version})
+}
+
+// UnsnoozeIncident lifts a snooze before it runs out.
+func (h *Handler) UnsnoozeIncident(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+	ctx := c.Request().Context()
+	err = h.repo.UnsnoozeIncident(ctx, id, auth.Actor(ctx))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found or not snoozed"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to unsnooze incident"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+func (h *Handler) TopServices(c echo.Context) error {
+	loc, err := requestTZ(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	since, err := parseSince(c.QueryParam("since"), 24*time.Hour, loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	limit, err := parseLimit(c.QueryParam("limit"), 10, 100)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	services, err := h.repo.TopServices(c.Request().Context(), since, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to compute service stats"})
+	}
+	return c.JSON(http.StatusOK, services)
+}
+
+func (h *Handler) IncidentsByService(c echo.Context) error {
+	loc, err := requestTZ(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	since, err := parseSince(c.QueryParam("since"), 7*24*time.Hour, loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	rollup, err := h.repo.IncidentsByService(c.Request().Context(), since)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to compute incident stats"})
+	}
+	return c.JSON(http.StatusOK, rollup)
+}
+
+// SLABreaches reports incidents created since ?since= (default 30 days) that
+// breached their acknowledge or resolve SLA.
+func (h *Handler) SLABreaches(c echo.Context) error {
+	loc, err := requestTZ(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	since, err := parseSince(c.QueryParam("since"), 30*24*time.Hour, loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	incidents, err := h.repo.ListIncidentsSince(c.Request().Context(), since)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to compute SLA breaches"})
+	}
+
+	h.slaTargets.Apply(incidents, time.Now())
+	h.severityDisplay.apply(incidents)
+	breaches := []store.Incident{}
+	ackBreaches, resolveBreaches := 0, 0
+	bySeverity := map[string]int{}
+	for _, inc := range incidents {
+		if inc.SLA == nil || !(inc.SLA.AckBreached || inc.SLA.ResolveBreached) {
+			continue
+		}
+		if inc.SLA.AckBreached {
+			ackBreaches++
+		}
+		if inc.SLA.ResolveBreached {
+			resolveBreaches++
+		}
+		bySeverity[inc.Severity]++
+		breaches = append(breaches, inc)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"since":            since,
+		"total":            len(incidents),
+		"breached":         len(breaches),
+		"ack_breaches":     ackBreaches,
+		"resolve_breaches": resolveBreaches,
+		"by_severity":      bySeverity,
+		"incidents":        breaches,
+	})
+}
+
+// logVolumeMaxBuckets bounds how many points one log-volume query returns.
+const logVolumeMaxBuckets = 2000
+
+// LogVolume returns log counts per ?interval= (default 1m) bucket and level
+// between ?since= (default 1h ago) and ?until= (default now), optionally for
+// one ?service=. Empty buckets are included so charts need no gap filling.
+// With ?tz=, naive since/until dates and bucket boundaries are in that zone.
+func (h *Handler) LogVolume(c echo.Context) error {
+	loc, err := requestTZ(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	since, err := parseSince(c.QueryParam("since"), time.Hour, loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	until, err := parseUntil(c.QueryParam("until"), loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if !until.After(since) {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "until must be after since"})
+	}
+	interval := time.Minute
+	if raw := c.QueryParam("interval"); raw != "" {
+		if interval, err = time.ParseDuration(raw); err != nil || interval < time.Second {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("invalid interval %q: use a duration of at least 1s", raw)})
+		}
+	}
+	if buckets := until.Sub(since) / interval; buckets > logVolumeMaxBuckets {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("range spans %d intervals; widen interval or narrow the range (max %d)", buckets, logVolumeMaxBuckets)})
+	}
+
+	// Align buckets to midnight in ?tz= so daily buckets are the client's
+	// days; the epoch is midnight UTC. Whole-day intervals are binned by
+	// calendar day in tz so DST changes don't shift them by an hour.
+	origin, tz := time.Unix(0, 0).UTC(), ""
+	if loc != time.UTC {
+		y, m, d := since.In(loc).Date()
+		origin, tz = time.Date(y, m, d, 0, 0, 0, 0, loc), loc.String()
+	}
+	service := c.QueryParam("service")
+	series, err := h.repo.LogVolume(c.Request().Context(), service, interval, since, until, origin, tz)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to compute log volume"})
+	}
+	if series == nil {
+		series = []store.VolumeBucket{}
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"service":  service,
+		"interval": interval.String(),
+		"since":    since,
+		"until":    until,
+		"buckets":  series,
+	})
+}
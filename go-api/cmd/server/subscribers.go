@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/metrics"
+)
+
+// subscriberRetryAfter is the Retry-After, in seconds, sent to a stream
+// subscriber turned away at the cap.
+const subscriberRetryAfter = 5
+
+// streamLimiter caps how many clients may hold a stream open at once, in
+// total and per route, so a connection storm cannot pile up goroutines and
+// buffers without bound. A limit of 0 means unlimited.
+type streamLimiter struct {
+	global  int64
+	routes  map[string]int64
+	metrics *metrics.Registry
+
+	mu     sync.Mutex
+	total  int64
+	active map[string]int64
+}
+
+// parseStreamLimits combines STREAM_MAX_SUBSCRIBERS, the cap across every
+// stream, with STREAM_ROUTE_MAX_SUBSCRIBERS, a JSON object of per-route caps
+// such as {"/api/incidents/:incident_id/summary/stream":20}.
+func parseStreamLimits(global int64, raw string, reg *metrics.Registry) (*streamLimiter, error) {
+	if global < 0 {
+		return nil, fmt.Errorf("global limit must not be negative, got %d", global)
+	}
+	l := &streamLimiter{global: global, metrics: reg, active: make(map[string]int64)}
+	if raw == "" {
+		return l, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &l.routes); err != nil {
+		return nil, fmt.Errorf("parse route limits: %w", err)
+	}
+	for route, n := range l.routes {
+		if n < 0 {
+			return nil, fmt.Errorf("route %s: limit must not be negative, got %d", route, n)
+		}
+	}
+	return l, nil
+}
+
+// acquire admits a subscriber to route unless that would exceed a cap.
+func (l *streamLimiter) acquire(route string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.global > 0 && l.total >= l.global {
+		return false
+	}
+	if n := l.routes[route]; n > 0 && l.active[route] >= n {
+		return false
+	}
+	l.total++
+	l.active[route]++
+	l.record(route)
+	return true
+}
+
+func (l *streamLimiter) release(route string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total--
+	l.active[route]--
+	l.record(route)
+}
+
+func (l *streamLimiter) record(route string) {
+	l.metrics.Gauge("stream_subscribers", "Clients currently holding a stream open.", "route", route).Set(float64(l.active[route]))
+}
+
+// middleware holds a subscriber slot for the life of the request, answering
+// 503 with Retry-After when the route or the server is at its cap.
+func (l *streamLimiter) middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route := c.Path()
+			if !l.acquire(route) {
+				l.metrics.Counter("stream_subscribers_rejected_total", "Stream subscribers turned away at the subscriber cap.", "route", route).Inc()
+				c.Response().Header().Set("Retry-After", strconv.Itoa(subscriberRetryAfter))
+				return c.JSON(http.StatusServiceUnavailable, echo.Map{"error": "too many stream subscribers, retry later"})
+			}
+			defer l.release(route)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// summaryImportMaxRows bounds one import, which is applied in a single
+// transaction.
+const summaryImportMaxRows = 10000
+
+type summaryImportResult struct {
+	Updated    int      `json:"updated"`
+	UnknownIDs []int64  `json:"unknown_ids"`
+	Rejected   int      `json:"rejected"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+func (r *summaryImportResult) reject(row int, err error) {
+	r.Rejected++
+	if len(r.Errors) < uploadMaxErrors {
+		r.Errors = append(r.Errors, fmt.Sprintf("row %d: %s", row, err))
+	}
+}
+
+// ImportSummaries stores analyses computed offline, from a CSV sent as the
+// "file" field of a multipart form with incident_id, summary and root_cause
+// columns. Valid rows are applied in one transaction; rows naming unknown
+// incidents are skipped and listed, and malformed or blank rows are
+// rejected without stopping the import.
+func (h *Handler) ImportSummaries(c echo.Context) error {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.maxUploadBytes)
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return uploadReadError(c, err, nil)
+		}
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "expected a multipart/form-data upload with a file field"})
+	}
+	f, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "failed to read upload"})
+	}
+	defer f.Close()
+
+	res := &summaryImportResult{UnknownIDs: []int64{}}
+	imports, err := h.readSummaryImport(f, res)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error(), "result": res})
+	}
+	if len(imports) == 0 {
+		return c.JSON(http.StatusOK, res)
+	}
+
+	found, err := h.repo.ImportIncidentSummaries(req.Context(), imports)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to import summaries; nothing was changed"})
+	}
+	for i, ok := range found {
+		if ok {
+			res.Updated++
+		} else {
+			res.UnknownIDs = append(res.UnknownIDs, imports[i].IncidentID)
+		}
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// readSummaryImport parses the CSV, rejecting bad rows into res. A repeated
+// incident_id is rejected too, so the result never depends on row order.
+func (h *Handler) readSummaryImport(r io.Reader, res *summaryImportResult) ([]store.SummaryImport, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing CSV header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"incident_id", "summary", "root_cause"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing column %q", required)
+		}
+	}
+
+	var imports []store.SummaryImport
+	seen := make(map[int64]int)
+	for row := 1; ; row++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			res.reject(row, parseErr.Err)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+		field := func(name string) string {
+			if i := cols[name]; i < len(rec) {
+				return rec[i]
+			}
+			return ""
+		}
+
+		id, err := strconv.ParseInt(strings.TrimSpace(field("incident_id")), 10, 64)
+		if err != nil || id <= 0 {
+			res.reject(row, fmt.Errorf("invalid incident_id %q", field("incident_id")))
+			continue
+		}
+		if first, ok := seen[id]; ok {
+			res.reject(row, fmt.Errorf("incident %d already given on row %d", id, first))
+			continue
+		}
+		summary, rootCause := field("summary"), field("root_cause")
+		if h.emptyAnalysis(summary, rootCause) {
+			blank := "summary"
+			if strings.TrimSpace(summary) != "" {
+				blank = "root_cause"
+			}
+			res.reject(row, fmt.Errorf("%s is blank", blank))
+			continue
+		}
+		if len(imports) == summaryImportMaxRows {
+			return nil, fmt.Errorf("import exceeds %d rows", summaryImportMaxRows)
+		}
+		seen[id] = row
+		imports = append(imports, store.SummaryImport{IncidentID: id, Summary: summary, RootCause: rootCause})
+	}
+	return imports, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// summaryStreamTimeout bounds a streamed analysis end to end; the ML
+// client's own timeout is meant for blocking calls and would cut a stream
+// short.
+const summaryStreamTimeout = 2 * time.Minute
+
+// sseStream writes server-sent events, pushing the write deadline out with
+// every event so a long analysis outlives the server's WriteTimeout.
+type sseStream struct {
+	res *echo.Response
+	rc  *http.ResponseController
+}
+
+func newSSEStream(res *echo.Response) *sseStream {
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	s := &sseStream{res: res, rc: http.NewResponseController(res)}
+	s.rc.SetWriteDeadline(time.Now().Add(exportWriteWindow))
+	res.Flush()
+	return s
+}
+
+func (s *sseStream) send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.res, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	s.res.Flush()
+	return s.rc.SetWriteDeadline(time.Now().Add(exportWriteWindow))
+}
+
+// StreamIncidentSummary is GetIncidentSummary over server-sent events. When
+// the ML service answers with an event stream, its "token" events are
+// relayed as they arrive; either way the stream ends with a "result" event
+// carrying the incident as GetIncidentSummary would return it, or an
+// "error" event. A cached or skipped analysis is a lone "result" event.
+func (h *Handler) StreamIncidentSummary(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+	incident, err := h.repo.GetIncident(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+
+	stream := newSSEStream(c.Response())
+	refreshStale, _ := strconv.ParseBool(c.QueryParam("refresh_stale"))
+	if h.hasAnalysis(incident) && !(refreshStale && h.summaryStale(incident)) {
+		return stream.send("result", incident)
+	}
+	force, _ := strconv.ParseBool(c.QueryParam("force"))
+	if !force && h.mlMinSeverity != "" && severityWeight[incident.Severity] < severityWeight[h.mlMinSeverity] {
+		return stream.send("result", summaryResponse{
+			Incident: incident,
+			Note:     fmt.Sprintf("analysis skipped: %s is below the %s minimum severity (use ?force=true to analyze anyway)", incident.Severity, h.mlMinSeverity),
+		})
+	}
+
+	summary, rootCause, reason := h.streamAnalysis(c, stream, incident)
+	if reason != "" {
+		stream.send("error", echo.Map{"error": reason})
+		if h.hasAnalysis(incident) {
+			return stream.send("result", summaryResponse{Incident: incident, Stale: true, Note: reason + "; returning the previous analysis"})
+		}
+		return nil
+	}
+
+	if err := h.repo.UpdateIncidentSummary(c.Request().Context(), id, summary, rootCause); err != nil {
+		return stream.send("error", echo.Map{"error": "failed to save summary"})
+	}
+	now := time.Now().UTC()
+	incident.Summary = &summary
+	incident.RootCause = &rootCause
+	incident.SummaryUpdatedAt = &now
+	return stream.send("result", incident)
+}
+
+// streamAnalysis asks the ML service for an analysis, preferring an event
+// stream and relaying its tokens. A non-empty reason means it failed.
+func (h *Handler) streamAnalysis(c echo.Context, stream *sseStream, incident *store.Incident) (summary, rootCause, reason string) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), summaryStreamTimeout)
+	defer cancel()
+
+	body, err := h.buildMLRequest(ctx, incident)
+	if err != nil {
+		return "", "", "failed to build ML request"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.mlService+"/analyze_incident", bytes.NewReader(body))
+	if err != nil {
+		return "", "", "failed to create ML request"
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream, application/json")
+
+	client := *h.httpClient
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil || resp.StatusCode >= 300 {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return "", "", "ML service unavailable"
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Summary   string `json:"summary"`
+		RootCause string `json:"root_cause"`
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		// The ML service doesn't stream; this is the blocking response.
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return "", "", "invalid ML response"
+		}
+		if h.emptyAnalysis(result.Summary, result.RootCause) {
+			return "", "", emptyAnalysisReason
+		}
+		return result.Summary, result.RootCause, ""
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, 1<<20)
+	var event string
+	var data []string
+	for sc.Scan() {
+		line := sc.Text()
+		if line != "" {
+			if v, ok := strings.CutPrefix(line, "event:"); ok {
+				event = strings.TrimSpace(v)
+			} else if v, ok := strings.CutPrefix(line, "data:"); ok {
+				data = append(data, strings.TrimPrefix(v, " "))
+			}
+			continue
+		}
+		if event == "" && len(data) == 0 {
+			continue
+		}
+		payload := strings.Join(data, "\n")
+		switch event {
+		case "result":
+			if err := json.Unmarshal([]byte(payload), &result); err != nil {
+				return "", "", "invalid ML response"
+			}
+			if h.emptyAnalysis(result.Summary, result.RootCause) {
+				return "", "", emptyAnalysisReason
+			}
+			return result.Summary, result.RootCause, ""
+		case "error":
+			return "", "", "ML analysis failed"
+		case "", "token":
+			if err := stream.send("token", echo.Map{"text": payload}); err != nil {
+				return "", "", "client disconnected"
+			}
+		}
+		event, data = "", nil
+	}
+	return "", "", "ML stream ended without a result"
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// summaryRepo holds one incident and records the analyses saved for it.
+type summaryRepo struct {
+	store.Repository
+	incident store.Incident
+	saved    int
+}
+
+func (r *summaryRepo) GetIncident(_ context.Context, id int64) (*store.Incident, error) {
+	if id != r.incident.ID {
+		return nil, store.ErrNotFound
+	}
+	inc := r.incident
+	return &inc, nil
+}
+
+func (r *summaryRepo) UpdateIncidentSummary(_ context.Context, _ int64, summary, rootCause string) error {
+	now := time.Now().UTC()
+	r.incident.Summary, r.incident.RootCause, r.incident.SummaryUpdatedAt = &summary, &rootCause, &now
+	r.saved++
+	return nil
+}
+
+func TestGetIncidentSummaryEmptyAnalysis(t *testing.T) {
+	blank := "  "
+	earlier := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name          string
+		allowEmptyRC  bool
+		stored        *string // summary and root cause already saved
+		ml            map[string]string
+		wantStatus    int
+		wantSaved     int
+		wantMLCalls   int
+		wantSummary   string
+		wantRootCause string
+	}{
+		{
+			name:        "blank summary",
+			ml:          map[string]string{"summary": "", "root_cause": "disk full"},
+			wantStatus:  http.StatusBadGateway,
+			wantMLCalls: 1,
+		},
+		{
+			name:        "blank root cause",
+			ml:          map[string]string{"summary": "db outage", "root_cause": " "},
+			wantStatus:  http.StatusBadGateway,
+			wantMLCalls: 1,
+		},
+		{
+			name:          "blank root cause allowed",
+			allowEmptyRC:  true,
+			ml:            map[string]string{"summary": "db outage", "root_cause": ""},
+			wantStatus:    http.StatusOK,
+			wantSaved:     1,
+			wantMLCalls:   1,
+			wantSummary:   "db outage",
+			wantRootCause: "",
+		},
+		{
+			name:          "blank stored analysis is redone",
+			stored:        &blank,
+			ml:            map[string]string{"summary": "db outage", "root_cause": "disk full"},
+			wantStatus:    http.StatusOK,
+			wantSaved:     1,
+			wantMLCalls:   1,
+			wantSummary:   "db outage",
+			wantRootCause: "disk full",
+		},
+		{
+			name:        "blank stored analysis is not served",
+			stored:      &blank,
+			ml:          map[string]string{"summary": "", "root_cause": ""},
+			wantStatus:  http.StatusBadGateway,
+			wantMLCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			ml := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				json.NewEncoder(w).Encode(tt.ml)
+			}))
+			defer ml.Close()
+
+			repo := &summaryRepo{incident: store.Incident{ID: 7, Severity: "high", Description: "errors", CreatedAt: earlier}}
+			if tt.stored != nil {
+				repo.incident.Summary, repo.incident.RootCause, repo.incident.SummaryUpdatedAt = tt.stored, tt.stored, &earlier
+			}
+			h := &Handler{repo: repo, mlService: ml.URL, httpClient: ml.Client(), mlAllowEmptyRootCause: tt.allowEmptyRC}
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/summary/7", nil), rec)
+			c.SetParamNames("incident_id")
+			c.SetParamValues("7")
+			if err := h.GetIncidentSummary(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if calls != tt.wantMLCalls {
+				t.Errorf("ML service called %d times, want %d", calls, tt.wantMLCalls)
+			}
+			if repo.saved != tt.wantSaved {
+				t.Errorf("analysis saved %d times, want %d", repo.saved, tt.wantSaved)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got store.Incident
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Summary == nil || *got.Summary != tt.wantSummary || got.RootCause == nil || *got.RootCause != tt.wantRootCause {
+				t.Errorf("served summary %v / root cause %v, want %q / %q", got.Summary, got.RootCause, tt.wantSummary, tt.wantRootCause)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const maxTags = 20
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]{0,63}$`)
+
+// normalizeTags lowercases and trims tags, drops empties and duplicates while
+// keeping the caller's order, and rejects anything outside tagPattern.
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		if !tagPattern.MatchString(t) {
+			return nil, fmt.Errorf("invalid tag %q", t)
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if len(out) > maxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+	return out, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+type CreateIncidentTemplateRequest struct {
+	Name                string   `json:"name" validate:"required,max=100,excludesall=/ "`
+	DefaultSeverity     string   `json:"default_severity" validate:"omitempty,oneof=low medium high critical"`
+	DescriptionTemplate string   `json:"description_template" validate:"required,max=5000"`
+	Tags                []string `json:"tags"`
+}
+
+type CreateIncidentFromTemplateRequest struct {
+	Vars     map[string]string `json:"vars"`
+	Severity string            `json:"severity" validate:"omitempty,oneof=low medium high critical"`
+	Service  *string           `json:"service" validate:"omitempty,max=200"`
+}
+
+func parseDescriptionTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
+
+func (h *Handler) CreateIncidentTemplate(c echo.Context) error {
+	var req CreateIncidentTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+	if _, err := parseDescriptionTemplate(req.Name, req.DescriptionTemplate); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid description template: " + err.Error()})
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	t := &store.IncidentTemplate{
+		Name:                req.Name,
+		DefaultSeverity:     req.DefaultSeverity,
+		DescriptionTemplate: req.DescriptionTemplate,
+		Tags:                tags,
+	}
+	if t.DefaultSeverity == "" {
+		t.DefaultSeverity = "medium"
+	}
+
+	err = h.repo.CreateIncidentTemplate(c.Request().Context(), t)
+	if errors.Is(err, store.ErrConflict) {
+		return c.JSON(http.StatusConflict, echo.Map{"error": "template already exists"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to create template"})
+	}
+	return c.JSON(http.StatusCreated, t)
+}
+
+func (h *Handler) ListIncidentTemplates(c echo.Context) error {
+	templates, err := h.repo.ListIncidentTemplates(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list templates"})
+	}
+	return c.JSON(http.StatusOK, templates)
+}
+
+func (h *Handler) GetIncidentTemplate(c echo.Context) error {
+	t, err := h.repo.GetIncidentTemplate(c.Request().Context(), c.Param("name"))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "template not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load template"})
+	}
+	return c.JSON(http.StatusOK, t)
+}
+
+func (h *Handler) DeleteIncidentTemplate(c echo.Context) error {
+	err := h.repo.DeleteIncidentTemplate(c.Request().Context(), c.Param("name"))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "template not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to delete template"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CreateIncidentFromTemplate files an incident from a named template, rendering
+// its description with the supplied vars (e.g. "{{.pool}}").
+func (h *Handler) CreateIncidentFromTemplate(c echo.Context) error {
+	var req CreateIncidentFromTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	ctx := c.Request().Context()
+	t, err := h.repo.GetIncidentTemplate(ctx, c.Param("name"))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "template not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to load template"})
+	}
+
+	tmpl, err := parseDescriptionTemplate(t.Name, t.DescriptionTemplate)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "stored template is invalid"})
+	}
+	var desc strings.Builder
+	if err := tmpl.Execute(&desc, req.Vars); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "failed to render template: " + err.Error()})
+	}
+	description, err := cleanText("rendered description", desc.String(), h.maxDescriptionLen)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	inc := &store.Incident{
+		Status:      "open",
+		Severity:    t.DefaultSeverity,
+		Description: description,
+		Service:     req.Service,
+		Tags:        t.Tags,
+	}
+	if req.Severity != "" {
+		inc.Severity = req.Severity
+	}
+	if _, err := h.repo.CreateIncident(ctx, inc); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to create incident"})
+	}
+	h.notifyIncidentOpened(inc)
+	inc.Display = h.severityDisplay.of(inc.Severity)
+	return c.JSON(http.StatusCreated, inc)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// streamingRoutes write their response incrementally, or bound their own
+// long-running work, and are exempt from request timeouts.
+var streamingRoutes = map[string]bool{
+	"/api/logs/upload":                           true,
+	"/api/incidents/export":                      true,
+	"/api/admin/maintenance":                     true,
+	"/api/incidents/:incident_id/summary/stream": true,
+}
+
+// serverTimeout is the HTTP server's read and write timeout.
+const serverTimeout = 15 * time.Second
+
+// deadlineSlack is how long past a request timeout the connection stays
+// open, leaving time to write the 503.
+const deadlineSlack = 5 * time.Second
+
+var (
+	timeoutBody = []byte(`{"error":"request timed out"}`)
+	// ingestTimeoutBody tells agents the batch is in an unknown state: some
+	// or all of it may have been stored before the deadline.
+	ingestTimeoutBody = []byte(`{"error":"ingestion timed out; the logs may not have been stored","stored":"unknown"}`)
+)
+
+// parseRouteDurations decodes a JSON object mapping route paths as
+// registered (e.g. "/api/summary/:incident_id") to durations, the format of
+// ROUTE_TIMEOUTS and CACHE_MAX_AGES.
+func parseRouteDurations(raw string) (map[string]time.Duration, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var spec map[string]string
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		return nil, fmt.Errorf("parse route timeouts: %w", err)
+	}
+	timeouts := make(map[string]time.Duration, len(spec))
+	for route, v := range spec {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", route, err)
+		}
+		timeouts[route] = d
+	}
+	return timeouts, nil
+}
+
+// timeoutMiddleware bounds each request's context by its route timeout, or
+// def when the route has none. JSON log ingestion gets ingest instead when
+// it is set. Timeouts too long for the server's own deadlines push those
+// deadlines back. A handler that is
+// still working when the deadline passes gets a 503 JSON response instead of
+// whatever it writes afterwards, so clients see a clean error rather than a
+// connection cut off by the server's WriteTimeout.
+func timeoutMiddleware(def time.Duration, routes map[string]time.Duration, ingest time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if streamingRoutes[c.Path()] {
+				return next(c)
+			}
+			if ingest > 0 && isIngestRoute(c) {
+				extendServerDeadlines(c, ingest)
+				return withDeadline(c, next, ingest, ingestTimeoutBody)
+			}
+			d, ok := routes[c.Path()]
+			if !ok {
+				d = def
+			}
+			if d <= 0 {
+				return next(c)
+			}
+			extendServerDeadlines(c, d)
+			return withDeadline(c, next, d, timeoutBody)
+		}
+	}
+}
+
+// extendServerDeadlines pushes the connection's read and write deadlines
+// past a request timeout of d when the server's own would cut the
+// connection first, so the 503 can still be written.
+func extendServerDeadlines(c echo.Context, d time.Duration) {
+	if d+deadlineSlack <= serverTimeout {
+		return
+	}
+	rc := http.NewResponseController(c.Response().Writer)
+	rc.SetReadDeadline(time.Now().Add(d + deadlineSlack))
+	rc.SetWriteDeadline(time.Now().Add(d + deadlineSlack))
+}
+
+// withDeadline runs next with the request context bounded by d, answering
+// 503 with body if the deadline passes before the response is committed.
+func withDeadline(c echo.Context, next echo.HandlerFunc, d time.Duration, body []byte) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), d)
+	defer cancel()
+	c.SetRequest(c.Request().WithContext(ctx))
+	res := c.Response()
+	res.Writer = &timeoutWriter{ResponseWriter: res.Writer, ctx: ctx, body: body}
+
+	err := next(c)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && !res.Committed {
+		return c.JSONBlob(http.StatusServiceUnavailable, body)
+	}
+	return err
+}
+
+// timeoutWriter swaps the response for a 503 when headers are first written
+// after the request deadline, and discards the handler's body.
+type timeoutWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	body        []byte
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if !errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.timedOut = true
+	h := w.ResponseWriter.Header()
+	h.Del("Content-Length")
+	h.Del("ETag")
+	h.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w.ResponseWriter.WriteHeader(http.StatusServiceUnavailable)
+	w.ResponseWriter.Write(w.body)
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.timedOut {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
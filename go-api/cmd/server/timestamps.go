@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseLogTimestamp accepts RFC3339 (optionally with fractional seconds) or
+// a Unix epoch number. Epoch values are read as seconds, milliseconds,
+// microseconds or nanoseconds depending on their magnitude, so any date
+// between 1973 and 5138 comes out right whichever unit the agent uses.
+func parseLogTimestamp(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return t, nil
+	}
+	n, err := strconv.ParseFloat(raw, 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) || math.IsNaN(n) {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: use RFC3339 or Unix epoch seconds or milliseconds", raw)
+	}
+	switch {
+	case n >= 1e17:
+		return time.Unix(0, int64(n)).UTC(), nil
+	case n >= 1e14:
+		return time.UnixMicro(int64(n)).UTC(), nil
+	case n >= 1e11:
+		return time.UnixMilli(int64(n)).UTC(), nil
+	default:
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+	}
+}
+
+// logTimestamp is a log's timestamp as sent in JSON: an RFC3339 string or an
+// epoch number, either bare or quoted. A value that parses as neither does
+// not fail the request; Invalid keeps it so the log can be stored at receipt
+// time and the fallback reported.
+type logTimestamp struct {
+	time.Time
+	Invalid string
+}
+
+func (t *logTimestamp) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	raw := string(b)
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &raw); err != nil {
+			return err
+		}
+	}
+	parsed, err := parseLogTimestamp(raw)
+	if err != nil {
+		t.Invalid = raw
+		return nil
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t logTimestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Time)
+}
+
+// timestampFallback describes why the log will be stored at receipt time
+// despite carrying a timestamp, or returns "" when it won't be.
+func (l IngestLog) timestampFallback() string {
+	if l.Timestamp == nil || l.Timestamp.Invalid == "" {
+		return ""
+	}
+	return fmt.Sprintf("unrecognised timestamp %q; stored with the receipt time", l.Timestamp.Invalid)
+}
+
+// clampFutureTimestamp stores a log dated more than skew ahead of now at now
+// instead, since agents' clocks drift but a log can't come from the future.
+// The sent time is kept in metadata. It reports whether the log was clamped.
+func clampFutureTimestamp(l *IngestLog, now time.Time, skew time.Duration) bool {
+	if l.Timestamp == nil || l.Timestamp.Invalid != "" || !l.Timestamp.After(now.Add(skew)) {
+		return false
+	}
+	if l.Metadata == nil {
+		l.Metadata = make(map[string]any, 2)
+	}
+	l.Metadata["timestamp_clamped"] = true
+	l.Metadata["original_timestamp"] = l.Timestamp.UTC().Format(time.RFC3339Nano)
+	l.Timestamp = &logTimestamp{Time: now}
+	return true
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// serve runs srv over TLS when a certificate is configured, otherwise over
+// plain HTTP. net/http negotiates HTTP/2 automatically on TLS listeners.
+func serve(srv *http.Server, cfg Config) error {
+	if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+		return srv.ListenAndServe()
+	}
+
+	srv.TLSConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if cfg.HTTPRedirectAddr != "" {
+		go serveHTTPSRedirect(cfg.HTTPRedirectAddr, srv.Addr)
+	}
+	return srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+}
+
+// serveHTTPSRedirect permanently redirects plain HTTP requests on addr to the
+// TLS listener at tlsAddr.
+func serveHTTPSRedirect(addr, tlsAddr string) {
+	_, tlsPort, _ := net.SplitHostPort(tlsAddr)
+	redirect := &http.Server{
+		Addr:         addr,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host := r.Host
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+			if tlsPort != "" && tlsPort != "443" {
+				host = net.JoinHostPort(host, tlsPort)
+			}
+			http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+		}),
+	}
+	log.Printf("redirecting HTTP on %s to HTTPS", addr)
+	if err := redirect.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("https redirect listener: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import "unicode/utf8"
+
+const truncationMarker = "... [truncated]"
+
+// truncateMessage shortens l.Message to at most max bytes (marker included),
+// cutting on a rune boundary and recording the original length in metadata.
+// A max of zero disables truncation.
+func truncateMessage(l *IngestLog, max int) {
+	if max <= 0 || len(l.Message) <= max {
+		return
+	}
+	originalLen := len(l.Message)
+
+	cut := max - len(truncationMarker)
+	if cut < 0 {
+		cut = 0
+	}
+	for cut > 0 && !utf8.RuneStart(l.Message[cut]) {
+		cut--
+	}
+	l.Message = l.Message[:cut] + truncationMarker
+	if len(l.Message) > max {
+		l.Message = l.Message[:max]
+	}
+
+	if l.Metadata == nil {
+		l.Metadata = make(map[string]any, 1)
+	}
+	l.Metadata["message_original_length"] = originalLen
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const (
+	uploadBatchSize   = 500
+	uploadMaxErrors   = 20
+	uploadMaxLineSize = 1 << 20
+
+	// logEntryOverhead approximates the per-row cost beyond a log's
+	// variable-length fields when sizing upload batches.
+	logEntryOverhead = 64
+)
+
+type uploadResult struct {
+	Inserted     int      `json:"inserted"`
+	DeadLettered int      `json:"dead_lettered,omitempty"`
+	Rejected     int      `json:"rejected"`
+	Clamped      int      `json:"clamped,omitempty"`
+	SampledOut   int      `json:"sampled_out,omitempty"`
+	Errors       []string `json:"errors,omitempty"`
+	Warnings     []string `json:"warnings,omitempty"`
+}
+
+func (r *uploadResult) reject(row int, err error) {
+	r.Rejected++
+	if len(r.Errors) < uploadMaxErrors {
+		r.Errors = append(r.Errors, fmt.Sprintf("row %d: %s", row, err))
+	}
+}
+
+func (r *uploadResult) warn(row int, warning string) {
+	if len(r.Warnings) < uploadMaxErrors {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("row %d: %s", row, warning))
+	}
+}
+
+func (h *Handler) uploadStoreError(c echo.Context, err error, res *uploadResult) error {
+	if retry, resp := h.retryLater(c, err, echo.Map{"result": res}); retry {
+		return resp
+	}
+	return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to store logs", "result": res})
+}
+
+// uploadRowReader returns the next parsed row. A non-nil rowErr rejects only
+// that row; a non-nil err aborts the upload (io.EOF when the file is done).
+type uploadRowReader func() (entry IngestLog, rowErr error, err error)
+
+// UploadLogs bulk-ingests an NDJSON or CSV file sent as the "file" field of a
+// multipart form. The file is parsed and inserted in bounded batches as it is
+// read, so it is never buffered in full. Debug logs are sampled as in
+// POST /api/logs, but per-service quotas don't apply: a file is a deliberate
+// bulk import, and a per-second budget would drop most of it.
+func (h *Handler) UploadLogs(c echo.Context) error {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.maxUploadBytes)
+
+	mr, err := req.MultipartReader()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "expected multipart/form-data upload"})
+	}
+
+	var part io.ReadCloser
+	var filename string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "missing file field"})
+		}
+		if err != nil {
+			return uploadReadError(c, err, nil)
+		}
+		if p.FormName() == "file" {
+			part, filename = p, p.FileName()
+			break
+		}
+		p.Close()
+	}
+	defer part.Close()
+
+	var next uploadRowReader
+	switch uploadFormat(c.QueryParam("format"), filename) {
+	case "csv":
+		next, err = csvRowReader(part)
+		if err != nil {
+			return uploadReadError(c, err, nil)
+		}
+	case "ndjson":
+		next = ndjsonRowReader(part)
+	default:
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "format must be ndjson or csv"})
+	}
+
+	ctx := req.Context()
+	now := time.Now().UTC()
+	res := &uploadResult{}
+	batch := make([]store.LogEntry, 0, uploadBatchSize)
+	batchRows := make([]int, 0, uploadBatchSize)
+	batchBytes := 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		_, deadLettered, err := h.insertLogs(ctx, batch)
+		var partial *store.PartialInsertError
+		switch {
+		case errors.As(err, &partial) && len(partial.Failed) == 0:
+			// The database turned down individual logs; reject those rows
+			// and keep going with the rest of the file.
+			res.Inserted += partial.Inserted
+			for _, row := range partial.Rows {
+				res.reject(batchRows[row.Index], row.Err)
+			}
+			if deadLettered {
+				res.DeadLettered += len(batch) - partial.Inserted - len(partial.Rows)
+			}
+		case partial != nil:
+			res.Inserted += partial.Inserted
+			return err
+		case err != nil:
+			return err
+		case deadLettered:
+			res.DeadLettered += len(batch)
+		default:
+			res.Inserted += len(batch)
+		}
+		batch = batch[:0]
+		batchRows = batchRows[:0]
+		batchBytes = 0
+		return nil
+	}
+
+	for row := 1; ; row++ {
+		l, rowErr, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return uploadReadError(c, err, res)
+		}
+		if rowErr != nil {
+			res.reject(row, rowErr)
+			continue
+		}
+		if err := checkIngestService(ctx, &l); err != nil {
+			res.reject(row, err)
+			continue
+		}
+		if err := h.ingestPolicy.apply(&l); err != nil {
+			res.reject(row, err)
+			continue
+		}
+		l.Level = h.normalizeLevel(l.Level)
+		truncateMessage(&l, h.maxMessageLen)
+		if clampFutureTimestamp(&l, now, h.clockSkew) {
+			res.Clamped++
+		}
+		entry, err := l.toEntry(now)
+		if err != nil {
+			res.reject(row, err)
+			continue
+		}
+		if warning := l.timestampFallback(); warning != "" {
+			res.warn(row, warning)
+		}
+		if !keepDebugLog(l, h.debugSampleRate) {
+			res.SampledOut++
+			continue
+		}
+		batch = append(batch, entry)
+		batchRows = append(batchRows, row)
+		batchBytes += entrySize(entry)
+		if len(batch) == uploadBatchSize || (h.uploadBatchBytes > 0 && batchBytes >= h.uploadBatchBytes) {
+			if err := flush(); err != nil {
+				return h.uploadStoreError(c, err, res)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return h.uploadStoreError(c, err, res)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// entrySize approximates the memory a buffered log entry holds.
+func entrySize(e store.LogEntry) int {
+	return len(e.Service) + len(e.Level) + len(e.Message) + len(e.Metadata) + logEntryOverhead
+}
+
+func uploadFormat(format, filename string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return "csv"
+	default:
+		return "ndjson"
+	}
+}
+
+func uploadReadError(c echo.Context, err error, res *uploadResult) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return c.JSON(http.StatusRequestEntityTooLarge, echo.Map{
+			"error":  fmt.Sprintf("upload exceeds %d bytes", maxErr.Limit),
+			"result": res,
+		})
+	}
+	return c.JSON(http.StatusBadRequest, echo.Map{"error": fmt.Sprintf("failed to read upload: %s", err), "result": res})
+}
+
+func ndjsonRowReader(r io.Reader) uploadRowReader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), uploadMaxLineSize)
+	return func() (IngestLog, error, error) {
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			var l IngestLog
+			if err := json.Unmarshal([]byte(line), &l); err != nil {
+				return IngestLog{}, errors.New("invalid JSON"), nil
+			}
+			return l, nil, nil
+		}
+		if err := sc.Err(); err != nil {
+			return IngestLog{}, nil, err
+		}
+		return IngestLog{}, nil, io.EOF
+	}
+}
+
+// csvRowReader expects a header row naming the columns; service, level and
+// message are required, timestamp (RFC3339 or Unix epoch) and metadata (JSON object) are
+// optional.
+func csvRowReader(r io.Reader) (uploadRowReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing CSV header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"service", "level", "message"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing column %q", required)
+		}
+	}
+
+	return func() (IngestLog, error, error) {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return IngestLog{}, nil, io.EOF
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return IngestLog{}, parseErr.Err, nil
+		}
+		if err != nil {
+			return IngestLog{}, nil, err
+		}
+
+		field := func(name string) string {
+			i, ok := cols[name]
+			if !ok || i >= len(rec) {
+				return ""
+			}
+			return rec[i]
+		}
+
+		l := IngestLog{
+			Service: field("service"),
+			Level:   field("level"),
+			Message: field("message"),
+		}
+		if ts := field("timestamp"); ts != "" {
+			t, err := parseLogTimestamp(ts)
+			if err != nil {
+				return IngestLog{}, err, nil
+			}
+			l.Timestamp = &logTimestamp{Time: t}
+		}
+		if meta := field("metadata"); meta != "" {
+			if err := json.Unmarshal([]byte(meta), &l.Metadata); err != nil {
+				return IngestLog{}, errors.New("metadata must be a JSON object"), nil
+			}
+		}
+		return l, nil, nil
+	}, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type requestValidator struct {
+	v *validator.Validate
+}
+
+func newRequestValidator() *requestValidator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "param", "query"} {
+			name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return f.Name
+	})
+	return &requestValidator{v: v}
+}
+
+func (rv *requestValidator) Validate(i any) error {
+	return rv.v.Struct(i)
+}
+
+var validate = newRequestValidator()
+
+type fieldProblem struct {
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+}
+
+// fieldProblems flattens validator errors into one entry per offending field,
+// using the JSON path (e.g. "logs[2].level") as the field name.
+func fieldProblems(err error) []fieldProblem {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return []fieldProblem{{Problem: err.Error()}}
+	}
+	problems := make([]fieldProblem, 0, len(verrs))
+	for _, fe := range verrs {
+		field := fe.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		} else {
+			field = fe.Field()
+		}
+		problems = append(problems, fieldProblem{Field: field, Problem: describeFieldError(fe)})
+	}
+	return problems
+}
+
+func describeFieldError(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must contain at most %s items", fe.Param())
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must contain at least %s items", fe.Param())
+	case "excludesall":
+		return fmt.Sprintf("must not contain any of %q", fe.Param())
+	case "http_url":
+		return "must be an absolute http(s) URL"
+	case "gtfield":
+		return fmt.Sprintf("must be after %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed %q validation", fe.Tag())
+	}
+}
+
+func validationError(c echo.Context, err error) error {
+	return c.JSON(http.StatusBadRequest, echo.Map{
+		"error":  "validation failed",
+		"fields": fieldProblems(err),
+	})
+}
+
+func joinProblems(problems []fieldProblem) string {
+	parts := make([]string, len(problems))
+	for i, p := range problems {
+		if p.Field == "" {
+			parts[i] = p.Problem
+		} else {
+			parts[i] = p.Field + " " + p.Problem
+		}
+	}
+	return strings.Join(parts, "; ")
+}
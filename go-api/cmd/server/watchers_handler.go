@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/notify"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const watcherNotifyTimeout = 30 * time.Second
+
+type WatchIncidentRequest struct {
+	Subscriber string `json:"subscriber" validate:"max=200"`
+}
+
+type CreateCommentRequest struct {
+	Body string `json:"body" validate:"required"`
+}
+
+// notifyWatchers messages every watcher of an incident in the background so
+// slow notification targets never hold up the request.
+func (h *Handler) notifyWatchers(incidentID int64, title, text string) {
+	if !h.notifier.Enabled() {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), watcherNotifyTimeout)
+		defer cancel()
+		notify.NotifyWatchers(ctx, h.repo, h.notifier, notify.Message{
+			IncidentID: incidentID,
+			Title:      title,
+			Text:       text,
+		})
+	}()
+}
+
+// notifyIncidentOpened announces a new incident in the background.
+func (h *Handler) notifyIncidentOpened(inc *store.Incident) {
+	if !h.notifier.Enabled() {
+		return
+	}
+	msg := notify.IncidentOpened(inc)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), watcherNotifyTimeout)
+		defer cancel()
+		h.notifier.Dispatch(ctx, msg)
+	}()
+}
+
+func (h *Handler) WatchIncident(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	var req WatchIncidentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+	if req.Subscriber == "" {
+		req.Subscriber = requestUser(c)
+	}
+	if req.Subscriber == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "subscriber is required"})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.repo.GetIncident(ctx, id); err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+	if err := h.repo.AddIncidentWatcher(ctx, id, req.Subscriber); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to add watcher"})
+	}
+	return c.JSON(http.StatusOK, echo.Map{"incident_id": id, "subscriber": req.Subscriber})
+}
+
+func (h *Handler) UnwatchIncident(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	err = h.repo.RemoveIncidentWatcher(c.Request().Context(), id, c.Param("subscriber"))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "watcher not found"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to remove watcher"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) ListIncidentWatchers(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	watchers, err := h.repo.ListIncidentWatchers(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list watchers"})
+	}
+	return c.JSON(http.StatusOK, watchers)
+}
+
+func (h *Handler) CreateIncidentComment(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	var req CreateCommentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+	body, err := cleanText("body", req.Body, h.maxCommentLen)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := h.repo.GetIncident(ctx, id); err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "incident not found"})
+	}
+
+	comment := &store.IncidentComment{IncidentID: id, Body: body}
+	if user := requestUser(c); user != "" {
+		comment.Author = &user
+	}
+	if err := h.repo.CreateIncidentComment(ctx, comment); err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to add comment"})
+	}
+
+	author := "someone"
+	if comment.Author != nil {
+		author = *comment.Author
+	}
+	h.notifyWatchers(id, fmt.Sprintf("New comment on incident #%d", id), fmt.Sprintf("%s: %s", author, comment.Body))
+
+	return c.JSON(http.StatusCreated, comment)
+}
+
+func (h *Handler) ListIncidentComments(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("incident_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid incident id"})
+	}
+
+	comments, err := h.repo.ListIncidentComments(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list comments"})
+	}
+	return c.JSON(http.StatusOK, comments)
+}
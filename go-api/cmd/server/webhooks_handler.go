@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const webhookReplayMax = 500
+
+// ListDeadWebhooks is the dead-letter view: notifications that failed every
+// attempt, oldest first.
+func (h *Handler) ListDeadWebhooks(c echo.Context) error {
+	limit, err := parseLimit(c.QueryParam("limit"), 100, webhookReplayMax)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	deliveries, err := h.repo.ListDeadWebhookDeliveries(c.Request().Context(), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list webhook deliveries"})
+	}
+	if deliveries == nil {
+		deliveries = []store.WebhookDelivery{}
+	}
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// RetryDeadWebhooks replays dead-lettered notifications oldest first. One
+// that fails again stays in the dead-letter view with its new error.
+func (h *Handler) RetryDeadWebhooks(c echo.Context) error {
+	limit, err := parseLimit(c.QueryParam("limit"), 100, webhookReplayMax)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
+	ctx := c.Request().Context()
+	deliveries, err := h.repo.ListDeadWebhookDeliveries(ctx, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "failed to list webhook deliveries"})
+	}
+
+	delivered, failed := 0, 0
+	for i := range deliveries {
+		del := &deliveries[i]
+		if err := h.notifier.Redeliver(ctx, del); err != nil {
+			log.Printf("replay webhook delivery %d: %v", del.ID, err)
+			failed++
+			continue
+		}
+		delivered++
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"delivered": delivered,
+		"failed":    failed,
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"Incident_Monitoring_Project/internal/auth"
+	"Incident_Monitoring_Project/internal/worker"
+)
+
+type ToggleWorkerRequest struct {
+	Paused *bool `json:"paused"`
+}
+
+// ListWorkers shows each background worker and whether it is paused.
+func (h *Handler) ListWorkers(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.workers.States())
+}
+
+// ToggleWorker pauses or resumes a background worker until the next restart:
+// {"paused":true} or false sets it, an empty body flips it. It is refused
+// unless WORKER_TOGGLES_ENABLED is set.
+func (h *Handler) ToggleWorker(c echo.Context) error {
+	if !h.workerToggles {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "worker toggles are disabled; set WORKER_TOGGLES_ENABLED=true to allow them"})
+	}
+	var req ToggleWorkerRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"error": "invalid payload"})
+		}
+	}
+
+	name := c.Param("name")
+	var current *worker.State
+	for _, s := range h.workers.States() {
+		if s.Name == name {
+			current = &s
+			break
+		}
+	}
+	if current == nil {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "worker not found"})
+	}
+	paused := !current.Paused
+	if req.Paused != nil {
+		paused = *req.Paused
+	}
+	if err := h.workers.SetPaused(name, paused); errors.Is(err, worker.ErrUnknownWorker) {
+		return c.JSON(http.StatusNotFound, echo.Map{"error": "worker not found"})
+	}
+	log.Printf("workers: %s set %s paused=%t", auth.Actor(c.Request().Context()), name, paused)
+
+	current.Paused = paused
+	return c.JSON(http.StatusOK, current)
+}
@@ -0,0 +1,42 @@
+package auth
+
+import "context"
+
+// SystemActor is recorded as the actor for changes made by background workers
+// and other unauthenticated internal callers.
+const SystemActor = "system"
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the acting principal.
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Principal returns the authenticated principal stored in ctx, if any.
+func Principal(ctx context.Context) (string, bool) {
+	p, ok := ctx.Value(principalKey{}).(string)
+	return p, ok && p != ""
+}
+
+// Actor returns the principal stored in ctx, or SystemActor when there is none.
+func Actor(ctx context.Context) string {
+	if p, ok := Principal(ctx); ok {
+		return p
+	}
+	return SystemActor
+}
+
+type ingestServiceKey struct{}
+
+// WithIngestService returns a copy of ctx whose log ingestion is restricted
+// to the given service, as bound to the ingestion token the caller presented.
+func WithIngestService(ctx context.Context, service string) context.Context {
+	return context.WithValue(ctx, ingestServiceKey{}, service)
+}
+
+// IngestService returns the service ingestion is restricted to, if any.
+func IngestService(ctx context.Context) (string, bool) {
+	s, ok := ctx.Value(ingestServiceKey{}).(string)
+	return s, ok && s != ""
+}
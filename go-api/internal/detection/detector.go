@@ -0,0 +1,139 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// Config controls burst detection: a service that logs Threshold or more
+// error-level entries within Window becomes an incident candidate. It is the
+// global default; store.DetectionRule overrides it per service.
+// SeverityRules raise a candidate's severity by keywords in its error
+// messages.
+type Config struct {
+	Window        time.Duration
+	Threshold     int
+	SeverityRules []SeverityRule
+}
+
+type Candidate struct {
+	Service     string    `json:"service"`
+	Severity    string    `json:"severity"`
+	Description string    `json:"description"`
+	ErrorCount  int       `json:"error_count"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	// SeverityRule is the keyword rule that matched the burst's messages,
+	// if any.
+	SeverityRule *SeverityRule `json:"severity_rule,omitempty"`
+}
+
+type Detector struct {
+	repo store.Repository
+	cfg  Config
+}
+
+func New(repo store.Repository, cfg Config) *Detector {
+	return &Detector{repo: repo, cfg: cfg}
+}
+
+// Evaluate returns the incident candidates for the window ending at until,
+// applying each service's detection rule where one exists. It has no side
+// effects, so it can be used for live detection and dry runs.
+func (d *Detector) Evaluate(ctx context.Context, until time.Time) ([]Candidate, error) {
+	rules, err := d.repo.ListDetectionRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	overridden := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		overridden[r.Service] = true
+	}
+
+	bursts, err := d.repo.ErrorBursts(ctx, until.Add(-d.cfg.Window), until, d.cfg.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]Candidate, 0, len(bursts))
+	for _, b := range bursts {
+		if overridden[b.Service] {
+			continue
+		}
+		c, err := d.candidate(ctx, b, d.cfg, until)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+
+	for _, r := range rules {
+		cfg := d.ruleConfig(r)
+		b, err := d.repo.ServiceErrorBurst(ctx, r.Service, until.Add(-cfg.Window), until, cfg.Threshold)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		c, err := d.candidate(ctx, *b, cfg, until)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// candidate builds the burst's candidate, looking through its error log
+// groups for severity keywords when any rules are configured.
+func (d *Detector) candidate(ctx context.Context, b store.ErrorBurst, cfg Config, until time.Time) (Candidate, error) {
+	c := candidateFor(b, cfg)
+	if len(cfg.SeverityRules) == 0 {
+		return c, nil
+	}
+	groups, err := d.repo.ErrorLogGroups(ctx, b.Service, until.Add(-cfg.Window), until, severityRuleGroups)
+	if err != nil {
+		return Candidate{}, err
+	}
+	applySeverityRule(&c, cfg.SeverityRules, groups)
+	return c, nil
+}
+
+// ruleConfig merges a service rule over the global config.
+func (d *Detector) ruleConfig(r store.DetectionRule) Config {
+	cfg := d.cfg
+	if r.WindowSeconds > 0 {
+		cfg.Window = time.Duration(r.WindowSeconds) * time.Second
+	}
+	if r.Threshold > 0 {
+		cfg.Threshold = r.Threshold
+	}
+	return cfg
+}
+
+func candidateFor(b store.ErrorBurst, cfg Config) Candidate {
+	return Candidate{
+		Service:     b.Service,
+		Severity:    severityFor(b.ErrorCount, cfg.Threshold),
+		Description: fmt.Sprintf("Error burst in %s: %d error logs within %s", b.Service, b.ErrorCount, cfg.Window),
+		ErrorCount:  b.ErrorCount,
+		FirstSeen:   b.FirstSeen,
+		LastSeen:    b.LastSeen,
+	}
+}
+
+func severityFor(count, threshold int) string {
+	switch {
+	case count >= 5*threshold:
+		return "critical"
+	case count >= 2*threshold:
+		return "high"
+	default:
+		return "medium"
+	}
+}
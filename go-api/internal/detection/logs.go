@@ -0,0 +1,119 @@
+package detection
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// LogVerdict is what detection concludes about one service from an explicit
+// set of logs: the most error logs that fall within any one window, and the
+// candidate that would be opened if that reaches the threshold.
+type LogVerdict struct {
+	Service      string     `json:"service"`
+	Logs         int        `json:"logs"`
+	ErrorLogs    int        `json:"error_logs"`
+	Window       string     `json:"window"`
+	Threshold    int        `json:"threshold"`
+	RuleOverride bool       `json:"rule_override"`
+	PeakCount    int        `json:"peak_count"`
+	WouldFire    bool       `json:"would_fire"`
+	Candidate    *Candidate `json:"candidate"`
+}
+
+// EvaluateLogs runs burst detection over just the given logs instead of the
+// logs table, applying each service's detection rule and the severity
+// rules, and reports a verdict per service. Like Evaluate, it has no side
+// effects.
+func (d *Detector) EvaluateLogs(ctx context.Context, logs []store.LogEntry) ([]LogVerdict, error) {
+	rules, err := d.repo.ListDetectionRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byService := make(map[string][]store.LogEntry)
+	for _, l := range logs {
+		byService[l.Service] = append(byService[l.Service], l)
+	}
+
+	res := make([]LogVerdict, 0, len(byService))
+	for _, service := range slices.Sorted(maps.Keys(byService)) {
+		cfg := d.cfg
+		v := LogVerdict{Service: service, Logs: len(byService[service])}
+		for _, r := range rules {
+			if r.Service == service {
+				cfg = d.ruleConfig(r)
+				v.RuleOverride = true
+				break
+			}
+		}
+		v.Window = cfg.Window.String()
+		v.Threshold = cfg.Threshold
+
+		var errs []store.LogEntry
+		for _, l := range byService[service] {
+			if slices.Contains(store.ErrorLevels, l.Level) {
+				errs = append(errs, l)
+			}
+		}
+		v.ErrorLogs = len(errs)
+		peak := peakWindow(errs, cfg.Window)
+		v.PeakCount = len(peak)
+		if len(peak) > 0 && len(peak) >= cfg.Threshold {
+			c := candidateFor(store.ErrorBurst{
+				Service:    service,
+				ErrorCount: len(peak),
+				FirstSeen:  peak[0].Timestamp,
+				LastSeen:   peak[len(peak)-1].Timestamp,
+			}, cfg)
+			applySeverityRule(&c, cfg.SeverityRules, groupLogs(peak))
+			v.WouldFire = true
+			v.Candidate = &c
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// peakWindow returns the largest run of logs whose timestamps fit within
+// one window, the earliest such run on a tie.
+func peakWindow(logs []store.LogEntry, window time.Duration) []store.LogEntry {
+	slices.SortFunc(logs, func(a, b store.LogEntry) int {
+		return cmp.Or(a.Timestamp.Compare(b.Timestamp), cmp.Compare(a.ID, b.ID))
+	})
+	var best []store.LogEntry
+	start := 0
+	for end := range logs {
+		for logs[end].Timestamp.Sub(logs[start].Timestamp) >= window {
+			start++
+		}
+		if end-start+1 > len(best) {
+			best = logs[start : end+1]
+		}
+	}
+	return best
+}
+
+// groupLogs groups logs by level and message the way ErrorLogGroups does,
+// largest group first.
+func groupLogs(logs []store.LogEntry) []store.LogGroup {
+	type key struct{ level, message string }
+	index := make(map[key]int)
+	var groups []store.LogGroup
+	for _, l := range logs {
+		k := key{l.Level, l.Message}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, store.LogGroup{Level: l.Level, Message: l.Message, FirstSeen: l.Timestamp})
+		}
+		groups[i].Count++
+		groups[i].LastSeen = l.Timestamp
+	}
+	slices.SortStableFunc(groups, func(a, b store.LogGroup) int { return cmp.Compare(b.Count, a.Count) })
+	return groups
+}
@@ -0,0 +1,73 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const previewGroupLimit = 20
+
+// Preview is what detection sees for one service right now.
+type Preview struct {
+	Service       string           `json:"service"`
+	Window        string           `json:"window"`
+	Threshold     int              `json:"threshold"`
+	RuleOverride  bool             `json:"rule_override"`
+	ErrorCount    int              `json:"error_count"`
+	WouldFire     bool             `json:"would_fire"`
+	InMaintenance bool             `json:"in_maintenance"`
+	Candidate     *Candidate       `json:"candidate"`
+	Groups        []store.LogGroup `json:"groups"`
+}
+
+// Preview evaluates a single service over the window ending at until, using
+// its detection rule or the global config, and returns the error log groups
+// behind the count. A positive window replaces the configured one. Like
+// Evaluate, it has no side effects.
+func (d *Detector) Preview(ctx context.Context, service string, window time.Duration, until time.Time) (*Preview, error) {
+	rules, err := d.repo.ListDetectionRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg := d.cfg
+	p := &Preview{Service: service}
+	for _, r := range rules {
+		if r.Service == service {
+			cfg = d.ruleConfig(r)
+			p.RuleOverride = true
+			break
+		}
+	}
+	if window > 0 {
+		cfg.Window = window
+	}
+	p.Window = cfg.Window.String()
+	p.Threshold = cfg.Threshold
+
+	since := until.Add(-cfg.Window)
+	b, err := d.repo.ServiceErrorBurst(ctx, service, since, until, 1)
+	if errors.Is(err, store.ErrNotFound) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.ErrorCount = b.ErrorCount
+
+	if p.Groups, err = d.repo.ErrorLogGroups(ctx, service, since, until, previewGroupLimit); err != nil {
+		return nil, err
+	}
+	if b.ErrorCount >= cfg.Threshold {
+		c := candidateFor(*b, cfg)
+		applySeverityRule(&c, cfg.SeverityRules, p.Groups)
+		p.WouldFire = true
+		p.Candidate = &c
+		if p.InMaintenance, err = d.repo.InMaintenance(ctx, service, until); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
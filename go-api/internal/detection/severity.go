@@ -0,0 +1,109 @@
+package detection
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// severityRuleGroups is how many of a burst's largest error log groups are
+// searched for severity keywords.
+const severityRuleGroups = 50
+
+// SeverityRule raises an auto-created incident to Severity when one of the
+// burst's error messages contains Keyword as a whole word, case-insensitively.
+type SeverityRule struct {
+	Keyword  string `json:"keyword"`
+	Severity string `json:"severity"`
+}
+
+// DefaultSeverityRules apply when SEVERITY_RULES is unset.
+var DefaultSeverityRules = []SeverityRule{
+	{Keyword: "panic", Severity: "critical"},
+	{Keyword: "out of memory", Severity: "critical"},
+	{Keyword: "oom", Severity: "critical"},
+	{Keyword: "deadlock", Severity: "critical"},
+	{Keyword: "timeout", Severity: "high"},
+	{Keyword: "timed out", Severity: "high"},
+}
+
+// ParseSeverityRules reads a JSON array of keyword rules. An empty string
+// yields DefaultSeverityRules; "[]" turns keyword inference off.
+func ParseSeverityRules(raw string) ([]SeverityRule, error) {
+	if raw == "" {
+		return DefaultSeverityRules, nil
+	}
+	var rules []SeverityRule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("parse severity rules: %w", err)
+	}
+	for i, r := range rules {
+		if strings.TrimSpace(r.Keyword) == "" {
+			return nil, fmt.Errorf("rule %d: keyword must not be empty", i)
+		}
+		if severityRank[r.Severity] == 0 {
+			return nil, fmt.Errorf("rule %d: unknown severity %q", i, r.Severity)
+		}
+	}
+	return rules, nil
+}
+
+// matchSeverityRule returns the most severe rule whose keyword appears in
+// any of the groups' messages, the earlier rule winning a tie.
+func matchSeverityRule(rules []SeverityRule, groups []store.LogGroup) *SeverityRule {
+	var match *SeverityRule
+	for i := range rules {
+		r := &rules[i]
+		if match != nil && severityRank[r.Severity] <= severityRank[match.Severity] {
+			continue
+		}
+		keyword := strings.ToLower(r.Keyword)
+		for _, g := range groups {
+			if containsWord(strings.ToLower(g.Message), keyword) {
+				match = r
+				break
+			}
+		}
+	}
+	return match
+}
+
+// containsWord reports whether word occurs in s without a letter or digit
+// on either side, so "oom" matches "OOM killed" but not "room".
+func containsWord(s, word string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// applySeverityRule raises the candidate's severity to the matching rule's,
+// recording the rule. A rule never lowers the burst-size severity.
+func applySeverityRule(c *Candidate, rules []SeverityRule, groups []store.LogGroup) {
+	r := matchSeverityRule(rules, groups)
+	if r == nil {
+		return
+	}
+	rule := *r
+	c.SeverityRule = &rule
+	if severityRank[r.Severity] > severityRank[c.Severity] {
+		c.Severity = r.Severity
+	}
+}
@@ -0,0 +1,76 @@
+package enrich
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// classifier sets metadata.category from keywords in the message, unless the
+// log already has one.
+type classifier struct{}
+
+var categories = []struct {
+	name     string
+	keywords []string
+}{
+	{"timeout", []string{"timeout", "timed out", "deadline exceeded"}},
+	{"connection", []string{"connection refused", "connection reset", "broken pipe", "no route to host", "unreachable"}},
+	{"auth", []string{"unauthorized", "forbidden", "permission denied", "invalid token", "authentication"}},
+	{"resource", []string{"out of memory", "oom", "no space left", "disk full", "too many open files"}},
+	{"database", []string{"deadlock", "sql", "database", "constraint"}},
+}
+
+func (classifier) Name() string { return "classify" }
+
+func (classifier) Enrich(_ context.Context, l *Log) error {
+	if _, ok := l.Metadata["category"]; ok {
+		return nil
+	}
+	msg := strings.ToLower(l.Message)
+	for _, c := range categories {
+		for _, kw := range c.keywords {
+			if strings.Contains(msg, kw) {
+				l.Metadata["category"] = c.name
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// ipScope adds <key>_scope (loopback, private, link_local or public) next to
+// each client address found under the common metadata keys.
+type ipScope struct{}
+
+var ipKeys = []string{"ip", "client_ip", "remote_addr"}
+
+func (ipScope) Name() string { return "ip_scope" }
+
+func (ipScope) Enrich(_ context.Context, l *Log) error {
+	for _, key := range ipKeys {
+		raw, ok := l.Metadata[key].(string)
+		if !ok || raw == "" {
+			continue
+		}
+		if host, _, err := net.SplitHostPort(raw); err == nil {
+			raw = host
+		}
+		addr, err := netip.ParseAddr(raw)
+		if err != nil {
+			return err
+		}
+		scope := "public"
+		switch {
+		case addr.IsLoopback():
+			scope = "loopback"
+		case addr.IsPrivate():
+			scope = "private"
+		case addr.IsLinkLocalUnicast():
+			scope = "link_local"
+		}
+		l.Metadata[key+"_scope"] = scope
+	}
+	return nil
+}
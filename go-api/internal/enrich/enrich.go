@@ -0,0 +1,94 @@
+// Package enrich runs ordered enrichers over logs as they are ingested, so
+// processing such as classification can be added without touching the
+// ingestion handlers.
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// Log is an ingested log as enrichers see it. Only Message and Metadata are
+// kept; service and level have already been validated and checked against
+// the ingestion token.
+type Log struct {
+	Service  string
+	Level    string
+	Message  string
+	Metadata map[string]any
+}
+
+type Enricher interface {
+	Name() string
+	Enrich(ctx context.Context, l *Log) error
+}
+
+// builtin are the enrichers that can be enabled by name.
+var builtin = map[string]func() Enricher{
+	"classify": func() Enricher { return classifier{} },
+	"ip_scope": func() Enricher { return ipScope{} },
+}
+
+// Pipeline applies its enrichers to each log in order.
+type Pipeline struct {
+	enrichers []Enricher
+}
+
+// New builds a pipeline from built-in enricher names, run in the order
+// given. No names yields a pipeline that does nothing.
+func New(names []string) (*Pipeline, error) {
+	p := &Pipeline{}
+	for _, name := range names {
+		mk, ok := builtin[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown enricher %q", name)
+		}
+		p.enrichers = append(p.enrichers, mk())
+	}
+	return p, nil
+}
+
+// Use appends enrichers to the pipeline.
+func (p *Pipeline) Use(enrichers ...Enricher) {
+	p.enrichers = append(p.enrichers, enrichers...)
+}
+
+func (p *Pipeline) Enabled() bool {
+	return p != nil && len(p.enrichers) > 0
+}
+
+// Apply enriches logs in place. An enricher that fails on a log is logged
+// and skipped; the log and the rest of the pipeline carry on.
+func (p *Pipeline) Apply(ctx context.Context, logs []store.LogEntry) {
+	if !p.Enabled() {
+		return
+	}
+	for i := range logs {
+		e := &logs[i]
+		l := Log{Service: e.Service, Level: e.Level, Message: e.Message}
+		if len(e.Metadata) > 0 {
+			if err := json.Unmarshal(e.Metadata, &l.Metadata); err != nil {
+				log.Printf("enrich: %s log: metadata: %v", e.Service, err)
+				continue
+			}
+		}
+		if l.Metadata == nil {
+			l.Metadata = make(map[string]any)
+		}
+		for _, en := range p.enrichers {
+			if err := en.Enrich(ctx, &l); err != nil {
+				log.Printf("enrich: %s: %s log: %v", en.Name(), e.Service, err)
+			}
+		}
+		meta, err := json.Marshal(l.Metadata)
+		if err != nil {
+			log.Printf("enrich: %s log: metadata: %v", e.Service, err)
+			continue
+		}
+		e.Message, e.Metadata = l.Message, meta
+	}
+}
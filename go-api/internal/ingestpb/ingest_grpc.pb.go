@@ -0,0 +1,123 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: ingest.proto
+
+package ingestpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LogIngestion_IngestLogs_FullMethodName = "/incidentmonitoring.ingest.v1.LogIngestion/IngestLogs"
+)
+
+// LogIngestionClient is the client API for LogIngestion service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// LogIngestion is the binary counterpart of POST /api/logs.
+type LogIngestionClient interface {
+	// IngestLogs stores each batch sent on the stream and answers every batch
+	// with one result, in order.
+	IngestLogs(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[IngestLogsRequest, IngestLogsResponse], error)
+}
+
+type logIngestionClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLogIngestionClient(cc grpc.ClientConnInterface) LogIngestionClient {
+	return &logIngestionClient{cc}
+}
+
+func (c *logIngestionClient) IngestLogs(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[IngestLogsRequest, IngestLogsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LogIngestion_ServiceDesc.Streams[0], LogIngestion_IngestLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[IngestLogsRequest, IngestLogsResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LogIngestion_IngestLogsClient = grpc.BidiStreamingClient[IngestLogsRequest, IngestLogsResponse]
+
+// LogIngestionServer is the server API for LogIngestion service.
+// All implementations must embed UnimplementedLogIngestionServer
+// for forward compatibility.
+//
+// LogIngestion is the binary counterpart of POST /api/logs.
+type LogIngestionServer interface {
+	// IngestLogs stores each batch sent on the stream and answers every batch
+	// with one result, in order.
+	IngestLogs(grpc.BidiStreamingServer[IngestLogsRequest, IngestLogsResponse]) error
+	mustEmbedUnimplementedLogIngestionServer()
+}
+
+// UnimplementedLogIngestionServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLogIngestionServer struct{}
+
+func (UnimplementedLogIngestionServer) IngestLogs(grpc.BidiStreamingServer[IngestLogsRequest, IngestLogsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method IngestLogs not implemented")
+}
+func (UnimplementedLogIngestionServer) mustEmbedUnimplementedLogIngestionServer() {}
+func (UnimplementedLogIngestionServer) testEmbeddedByValue()                      {}
+
+// UnsafeLogIngestionServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LogIngestionServer will
+// result in compilation errors.
+type UnsafeLogIngestionServer interface {
+	mustEmbedUnimplementedLogIngestionServer()
+}
+
+func RegisterLogIngestionServer(s grpc.ServiceRegistrar, srv LogIngestionServer) {
+	// If the following call pancis, it indicates UnimplementedLogIngestionServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LogIngestion_ServiceDesc, srv)
+}
+
+func _LogIngestion_IngestLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LogIngestionServer).IngestLogs(&grpc.GenericServerStream[IngestLogsRequest, IngestLogsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LogIngestion_IngestLogsServer = grpc.BidiStreamingServer[IngestLogsRequest, IngestLogsResponse]
+
+// LogIngestion_ServiceDesc is the grpc.ServiceDesc for LogIngestion service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LogIngestion_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "incidentmonitoring.ingest.v1.LogIngestion",
+	HandlerType: (*LogIngestionServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "IngestLogs",
+			Handler:       _LogIngestion_IngestLogs_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "ingest.proto",
+}
@@ -0,0 +1,304 @@
+// Package jobs runs long operations started through the API in the
+// background, a bounded number at a time, recording their progress in the
+// jobs table so it can be polled and outlives the process.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"Incident_Monitoring_Project/internal/auth"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const (
+	// flushInterval is how often an unfinished job's progress is saved,
+	// which doubles as its heartbeat.
+	flushInterval = 2 * time.Second
+	// maxErrors is how many item failures a job keeps.
+	maxErrors = 20
+)
+
+// Lease is how long a job may go without a heartbeat before its owner is
+// taken to be gone and the job is marked interrupted.
+const Lease = time.Minute
+
+// ErrKindRunning is returned by Submit for an exclusive job while another
+// job of its kind is still queued or running on any instance.
+var ErrKindRunning = errors.New("a job of this kind is already running")
+
+// Func is a job's work. It reports progress through p and returns a result
+// to record, which must encode as JSON. It should return promptly once ctx
+// is canceled.
+type Func func(ctx context.Context, p *Progress) (result any, err error)
+
+// Manager runs submitted jobs, at most concurrency at a time; the rest wait
+// in submission order. Jobs run until they finish or the manager is shut
+// down, and are recorded as owned by this instance.
+type Manager struct {
+	repo   store.Repository
+	owner  string
+	slots  chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	active map[string]*Progress
+}
+
+func NewManager(parent context.Context, repo store.Repository, concurrency int) *Manager {
+	ctx, cancel := context.WithCancel(parent)
+	return &Manager{
+		repo:   repo,
+		owner:  instanceName(),
+		slots:  make(chan struct{}, max(concurrency, 1)),
+		ctx:    ctx,
+		cancel: cancel,
+		active: make(map[string]*Progress),
+	}
+}
+
+// instanceName identifies this process among the API instances sharing the
+// jobs table.
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// Progress is a job in flight. Its counters are kept in memory and saved
+// periodically, so Get sees them before they reach the database.
+type Progress struct {
+	mu  sync.Mutex
+	job store.Job
+}
+
+// SetTotal sets how many items the job will work through.
+func (p *Progress) SetTotal(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Total = n
+}
+
+// Succeed counts an item done.
+func (p *Progress) Succeed() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Succeeded++
+}
+
+// Fail counts an item failed, keeping the first few reasons.
+func (p *Progress) Fail(item, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Failed++
+	if len(p.job.Errors) < maxErrors {
+		p.job.Errors = append(p.job.Errors, fmt.Sprintf("%s: %s", item, reason))
+	}
+}
+
+func (p *Progress) snapshot() store.Job {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	j := p.job
+	j.Errors = slices.Clone(j.Errors)
+	return j
+}
+
+func (p *Progress) update(fn func(j *store.Job)) store.Job {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fn(&p.job)
+	j := p.job
+	j.Errors = slices.Clone(j.Errors)
+	return j
+}
+
+// Recover marks unfinished jobs whose owner stopped heartbeating more than
+// a Lease ago as interrupted, such as those of a previous process.
+func (m *Manager) Recover(ctx context.Context) error {
+	n, err := m.repo.InterruptJobs(ctx, time.Now().Add(-Lease))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("jobs: marked %d unfinished jobs interrupted", n)
+	}
+	return nil
+}
+
+// Submit records a queued job of kind, attributed to the caller in ctx, and
+// starts it once a slot is free. An exclusive job is refused with
+// ErrKindRunning while another of its kind is unfinished.
+func (m *Manager) Submit(ctx context.Context, kind string, exclusive bool, fn Func) (*store.Job, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	job := store.Job{
+		ID:        hex.EncodeToString(id),
+		Kind:      kind,
+		Status:    store.JobQueued,
+		CreatedBy: auth.Actor(ctx),
+		Owner:     m.owner,
+		Exclusive: exclusive,
+	}
+	err := m.repo.CreateJob(ctx, &job)
+	if errors.Is(err, store.ErrConflict) {
+		return nil, ErrKindRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Progress{job: job}
+	m.mu.Lock()
+	m.active[job.ID] = p
+	m.mu.Unlock()
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.run(m.ctx, p, fn)
+	}()
+	return &job, nil
+}
+
+// Shutdown cancels every job and waits for them to record how they ended,
+// or for ctx to end. It reports whether all of them did.
+func (m *Manager) Shutdown(ctx context.Context) bool {
+	m.cancel()
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		log.Printf("jobs: %d jobs still running at shutdown", m.count())
+		return false
+	}
+}
+
+func (m *Manager) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.active)
+}
+
+// Get returns a job, from memory while it is unfinished.
+func (m *Manager) Get(ctx context.Context, id string) (*store.Job, error) {
+	m.mu.Lock()
+	p, ok := m.active[id]
+	m.mu.Unlock()
+	if ok {
+		j := p.snapshot()
+		return &j, nil
+	}
+	return m.repo.GetJob(ctx, id)
+}
+
+func (m *Manager) forget(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.active, id)
+}
+
+func (m *Manager) run(ctx context.Context, p *Progress, fn Func) {
+	defer m.forget(p.job.ID)
+	save := context.WithoutCancel(ctx)
+
+	// Saves keep the job's heartbeat fresh from the start, so a job
+	// waiting for a slot isn't mistaken for one whose owner is gone.
+	stop := make(chan struct{})
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				m.save(save, p, p.snapshot())
+			}
+		}
+	}()
+	stopFlushing := func() {
+		close(stop)
+		<-flushed
+	}
+
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		stopFlushing()
+		m.save(save, p, p.update(func(j *store.Job) { finish(j, store.JobCanceled) }))
+		return
+	}
+
+	m.save(save, p, p.update(func(j *store.Job) {
+		now := time.Now().UTC()
+		j.Status = store.JobRunning
+		j.StartedAt = &now
+	}))
+
+	result, err := fn(ctx, p)
+	stopFlushing()
+
+	m.save(save, p, p.update(func(j *store.Job) {
+		switch {
+		case err != nil && ctx.Err() != nil:
+			j.Error = err.Error()
+			finish(j, store.JobCanceled)
+		case err != nil:
+			j.Error = err.Error()
+			finish(j, store.JobFailed)
+		default:
+			if result != nil {
+				if j.Result, err = json.Marshal(result); err != nil {
+					j.Error = fmt.Sprintf("encode result: %v", err)
+					finish(j, store.JobFailed)
+					return
+				}
+			}
+			finish(j, store.JobDone)
+		}
+	}))
+}
+
+func finish(j *store.Job, status string) {
+	now := time.Now().UTC()
+	j.Status = status
+	j.FinishedAt = &now
+}
+
+// save writes j, a snapshot of p, and keeps p's heartbeat in step.
+func (m *Manager) save(ctx context.Context, p *Progress, j store.Job) {
+	err := m.repo.SaveJob(ctx, &j)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		log.Printf("jobs: job %s was marked interrupted while it ran; its progress is no longer saved", j.ID)
+	case err != nil:
+		log.Printf("jobs: saving job %s: %v", j.ID, err)
+	default:
+		p.update(func(pj *store.Job) { pj.HeartbeatAt = j.HeartbeatAt })
+	}
+}
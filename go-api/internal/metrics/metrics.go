@@ -0,0 +1,132 @@
+// Package metrics keeps a small set of gauges and counters and serves them
+// in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry holds metrics by name. Metrics sharing a name but differing in
+// labels are exposed as one family.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+type family struct {
+	name, help, kind string
+	series           map[string]*value
+}
+
+type value struct{ bits atomic.Uint64 }
+
+func (v *value) load() float64 { return math.Float64frombits(v.bits.Load()) }
+
+func (v *value) add(d float64) {
+	for {
+		old := v.bits.Load()
+		if v.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+d)) {
+			return
+		}
+	}
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct{ v *value }
+
+func (g Gauge) Set(x float64) { g.v.bits.Store(math.Float64bits(x)) }
+
+// Counter is a value that only increases.
+type Counter struct{ v *value }
+
+func (c Counter) Inc()          { c.v.add(1) }
+func (c Counter) Add(d float64) { c.v.add(d) }
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Gauge returns the gauge with the given name and label pairs
+// ("key", "value", ...), creating it on first use.
+func (r *Registry) Gauge(name, help string, labels ...string) Gauge {
+	return Gauge{r.series(name, help, "gauge", labels)}
+}
+
+// Counter returns the counter with the given name and label pairs, creating
+// it on first use.
+func (r *Registry) Counter(name, help string, labels ...string) Counter {
+	return Counter{r.series(name, help, "counter", labels)}
+}
+
+func (r *Registry) series(name, help, kind string, labels []string) *value {
+	if len(labels)%2 != 0 {
+		panic("metrics: labels must be key/value pairs")
+	}
+	var key strings.Builder
+	for i := 0; i < len(labels); i += 2 {
+		if i > 0 {
+			key.WriteByte(',')
+		}
+		fmt.Fprintf(&key, "%s=%s", labels[i], strconv.Quote(labels[i+1]))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: help, kind: kind, series: make(map[string]*value)}
+		r.families[name] = f
+	}
+	if f.kind != kind {
+		panic(fmt.Sprintf("metrics: %s registered as both %s and %s", name, f.kind, kind))
+	}
+	v, ok := f.series[key.String()]
+	if !ok {
+		v = &value{}
+		f.series[key.String()] = v
+	}
+	return v
+}
+
+// ServeHTTP writes every metric in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := r.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		keys := make([]string, 0, len(f.series))
+		for k := range f.series {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if k == "" {
+				fmt.Fprintf(&b, "%s %s\n", f.name, formatValue(f.series[k].load()))
+			} else {
+				fmt.Fprintf(&b, "%s{%s} %s\n", f.name, k, formatValue(f.series[k].load()))
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+func formatValue(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"Incident_Monitoring_Project/internal/metrics"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// RetryPolicy bounds redelivery of failed notifications: attempt n waits
+// BaseDelay doubled n-1 times, capped at MaxDelay, and a delivery is dead
+// once MaxAttempts attempts have failed.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func (p RetryPolicy) backoff(attempts int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempts && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDelay)
+}
+
+// DeliveryStore persists delivery records.
+type DeliveryStore interface {
+	CreateWebhookDelivery(ctx context.Context, d *store.WebhookDelivery) error
+	UpdateWebhookDelivery(ctx context.Context, d *store.WebhookDelivery) error
+}
+
+// TrackDeliveries records every delivery in s so failed ones are retried
+// under policy, and counts outcomes in reg as notify_deliveries_total.
+func (d *Dispatcher) TrackDeliveries(s DeliveryStore, reg *metrics.Registry, policy RetryPolicy) {
+	d.deliveries = s
+	d.metrics = reg
+	d.retry = policy
+}
+
+// deliver sends msg through n, recording the attempt when deliveries are
+// tracked.
+func (d *Dispatcher) deliver(ctx context.Context, n Notifier, msg Message) {
+	err := n.Notify(ctx, msg)
+	if err != nil {
+		log.Printf("notify: %s: incident %d: %v", n.Name(), msg.IncidentID, err)
+	}
+	if d.deliveries == nil {
+		return
+	}
+	payload, merr := json.Marshal(msg)
+	if merr != nil {
+		log.Printf("notify: %s: incident %d: recording delivery: %v", n.Name(), msg.IncidentID, merr)
+		return
+	}
+	del := &store.WebhookDelivery{Notifier: n.Name(), IncidentID: msg.IncidentID, Payload: payload}
+	d.settle(del, err)
+	if err := d.deliveries.CreateWebhookDelivery(ctx, del); err != nil {
+		log.Printf("notify: %s: incident %d: recording delivery: %v", n.Name(), msg.IncidentID, err)
+	}
+}
+
+// Redeliver makes another attempt at a recorded delivery and saves the
+// outcome. A dead delivery replayed this way stays dead if it fails again.
+func (d *Dispatcher) Redeliver(ctx context.Context, del *store.WebhookDelivery) error {
+	var n Notifier
+	for _, candidate := range d.notifiers {
+		if candidate.Name() == del.Notifier {
+			n = candidate
+			break
+		}
+	}
+	var msg Message
+	err := json.Unmarshal(del.Payload, &msg)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("invalid payload: %w", err)
+	case n == nil:
+		err = fmt.Errorf("notifier %q is no longer configured", del.Notifier)
+	default:
+		err = n.Notify(ctx, msg)
+	}
+	d.settle(del, err)
+	if serr := d.deliveries.UpdateWebhookDelivery(ctx, del); serr != nil {
+		return serr
+	}
+	return err
+}
+
+// settle applies the outcome of an attempt to del and counts it.
+func (d *Dispatcher) settle(del *store.WebhookDelivery, err error) {
+	del.Attempts++
+	now := time.Now().UTC()
+	switch {
+	case err == nil:
+		del.Status, del.LastError, del.NextAttemptAt, del.DeliveredAt = store.DeliveryDelivered, "", nil, &now
+	case del.Status == store.DeliveryDead || del.Attempts >= d.retry.MaxAttempts:
+		del.Status, del.LastError, del.NextAttemptAt = store.DeliveryDead, err.Error(), nil
+	default:
+		next := now.Add(d.retry.backoff(del.Attempts))
+		del.Status, del.LastError, del.NextAttemptAt = store.DeliveryPending, err.Error(), &next
+	}
+	if d.metrics != nil {
+		outcome := del.Status
+		if outcome == store.DeliveryPending {
+			outcome = "retrying"
+		}
+		d.metrics.Counter("notify_deliveries_total", "Notification delivery attempts by notifier and outcome.", "notifier", del.Notifier, "outcome", outcome).Inc()
+	}
+}
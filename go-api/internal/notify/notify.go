@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"Incident_Monitoring_Project/internal/metrics"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// Message is a single notification about an incident. Recipient is set when
+// the message is addressed to one watcher rather than broadcast.
+type Message struct {
+	IncidentID int64  `json:"incident_id"`
+	Severity   string `json:"severity,omitempty"`
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+	Recipient  string `json:"recipient,omitempty"`
+	// Urgent messages, such as escalations and SLA breaches, are never
+	// held back by a cooldown.
+	Urgent bool `json:"-"`
+}
+
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Dispatcher fans a message out to every configured notifier. Delivery
+// failures are logged and never returned, so callers can fire and forget;
+// with TrackDeliveries they are also recorded for retry.
+type Dispatcher struct {
+	notifiers []Notifier
+	routes    map[string][]string
+
+	deliveries DeliveryStore
+	metrics    *metrics.Registry
+	retry      RetryPolicy
+
+	throttle *throttle
+}
+
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{notifiers: notifiers}
+}
+
+func (d *Dispatcher) Enabled() bool {
+	return d != nil && len(d.notifiers) > 0
+}
+
+// SetRoutes restricts which notifiers receive each severity, keyed by
+// severity with notifier names as values, e.g.
+// {"critical":["pagerduty","slack"],"high":["slack"]}. Severities missing
+// from routes notify nobody; messages without a severity, such as watcher
+// updates, still go to every notifier. A nil routes map restores fan-out to
+// all notifiers.
+func (d *Dispatcher) SetRoutes(routes map[string][]string) error {
+	for severity, names := range routes {
+		if err := d.CheckNames(names); err != nil {
+			return fmt.Errorf("severity %q: %w", severity, err)
+		}
+	}
+	d.routes = routes
+	return nil
+}
+
+// CheckNames reports an error naming the first entry of names that is not a
+// configured notifier.
+func (d *Dispatcher) CheckNames(names []string) error {
+	for _, name := range names {
+		if !slices.ContainsFunc(d.notifiers, func(n Notifier) bool { return n.Name() == name }) {
+			return fmt.Errorf("unconfigured notifier %q", name)
+		}
+	}
+	return nil
+}
+
+// ParseRoutes decodes a SetRoutes mapping from JSON. An empty string yields
+// nil routes.
+func ParseRoutes(raw string) (map[string][]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var routes map[string][]string
+	if err := json.Unmarshal([]byte(raw), &routes); err != nil {
+		return nil, fmt.Errorf("parse severity routes: %w", err)
+	}
+	return routes, nil
+}
+
+// Dispatch sends msg to every notifier its severity routes to, unless a
+// cooldown set with SetCooldown holds it back. Urgent messages skip the
+// cooldown.
+func (d *Dispatcher) Dispatch(ctx context.Context, msg Message) {
+	if d == nil {
+		return
+	}
+	if d.throttle != nil && !msg.Urgent && !d.throttle.admit(msg) {
+		if d.metrics != nil {
+			d.metrics.Counter("notify_coalesced_total", "Notifications held back by NOTIFY_COOLDOWN for a summary.").Inc()
+		}
+		return
+	}
+	d.send(ctx, msg)
+}
+
+func (d *Dispatcher) send(ctx context.Context, msg Message) {
+	for _, n := range d.notifiers {
+		if !d.routed(msg.Severity, n.Name()) {
+			continue
+		}
+		d.deliver(ctx, n, msg)
+	}
+}
+
+// DispatchTo sends msg to the named notifiers only, bypassing severity
+// routes.
+func (d *Dispatcher) DispatchTo(ctx context.Context, names []string, msg Message) {
+	if d == nil {
+		return
+	}
+	for _, n := range d.notifiers {
+		if !slices.Contains(names, n.Name()) {
+			continue
+		}
+		d.deliver(ctx, n, msg)
+	}
+}
+
+func (d *Dispatcher) routed(severity, notifier string) bool {
+	if d.routes == nil || severity == "" {
+		return true
+	}
+	for _, name := range d.routes[severity] {
+		if name == notifier {
+			return true
+		}
+	}
+	return false
+}
+
+// IncidentOpened builds the broadcast announcing a new incident. It carries
+// the incident's severity so routes decide who hears about it.
+func IncidentOpened(inc *store.Incident) Message {
+	title := fmt.Sprintf("New %s incident #%d", inc.Severity, inc.ID)
+	if inc.Service != nil {
+		title = fmt.Sprintf("%s (%s)", title, *inc.Service)
+	}
+	return Message{
+		IncidentID: inc.ID,
+		Severity:   inc.Severity,
+		Title:      title,
+		Text:       inc.Description,
+	}
+}
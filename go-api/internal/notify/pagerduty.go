@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyNotifier triggers PagerDuty Events API v2 alerts, deduplicated per
+// incident.
+type PagerDutyNotifier struct {
+	routingKey string
+	client     *http.Client
+}
+
+func NewPagerDutyNotifier(routingKey string, client *http.Client) *PagerDutyNotifier {
+	return &PagerDutyNotifier{routingKey: routingKey, client: client}
+}
+
+func (n *PagerDutyNotifier) Name() string { return "pagerduty" }
+
+func (n *PagerDutyNotifier) Notify(ctx context.Context, msg Message) error {
+	summary := msg.Title
+	if msg.Text != "" {
+		summary = fmt.Sprintf("%s: %s", msg.Title, msg.Text)
+	}
+	return postJSON(ctx, n.client, pagerDutyEventsURL, map[string]any{
+		"routing_key":  n.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    fmt.Sprintf("incident-%d", msg.IncidentID),
+		"payload": map[string]string{
+			"summary":  summary,
+			"source":   "incident-monitoring",
+			"severity": pagerDutySeverity(msg.Severity),
+		},
+	})
+}
+
+func pagerDutySeverity(severity string) string {
+	switch severity {
+	case "critical":
+		return "critical"
+	case "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// SlackNotifier posts to a Slack incoming webhook.
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewSlackNotifier(url string, client *http.Client) *SlackNotifier {
+	return &SlackNotifier{url: url, client: client}
+}
+
+func (n *SlackNotifier) Name() string { return "slack" }
+
+func (n *SlackNotifier) Notify(ctx context.Context, msg Message) error {
+	text := fmt.Sprintf("*%s*\n%s", msg.Title, msg.Text)
+	if msg.Recipient != "" {
+		text = fmt.Sprintf("@%s %s", msg.Recipient, text)
+	}
+	return postJSON(ctx, n.client, n.url, map[string]string{"text": text})
+}
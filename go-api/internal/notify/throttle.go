@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// summaryTimeout bounds delivery of a coalesced summary, which is sent from
+// a timer rather than on behalf of any request.
+const summaryTimeout = 30 * time.Second
+
+// throttleKey identifies one stream of notifications: an incident's
+// broadcasts, or its updates to one watcher.
+type throttleKey struct {
+	incident  int64
+	recipient string
+}
+
+// throttle lets the first notification for a key through and holds back
+// the rest until its cooldown ends, when they go out as one summary.
+type throttle struct {
+	cooldown time.Duration
+	send     func(ctx context.Context, msg Message)
+
+	mu      sync.Mutex
+	windows map[throttleKey][]Message
+}
+
+// SetCooldown coalesces notifications per incident and recipient: after
+// one is sent, any more within cooldown are held and then sent as a single
+// summary of changes, which starts a new cooldown if there was anything to
+// summarize. Urgent messages and DispatchTo are never held back. Zero
+// turns coalescing off.
+func (d *Dispatcher) SetCooldown(cooldown time.Duration) {
+	if cooldown <= 0 {
+		d.throttle = nil
+		return
+	}
+	d.throttle = &throttle{cooldown: cooldown, send: d.send, windows: make(map[throttleKey][]Message)}
+}
+
+// admit reports whether msg may go out now. Otherwise it is held for the
+// summary at the end of the current cooldown.
+func (t *throttle) admit(msg Message) bool {
+	key := throttleKey{msg.IncidentID, msg.Recipient}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if pending, ok := t.windows[key]; ok {
+		t.windows[key] = append(pending, msg)
+		return false
+	}
+	t.open(key)
+	return true
+}
+
+func (t *throttle) open(key throttleKey) {
+	t.windows[key] = nil
+	time.AfterFunc(t.cooldown, func() { t.close(key) })
+}
+
+// close ends key's cooldown, sending what it held back as one summary.
+func (t *throttle) close(key throttleKey) {
+	t.mu.Lock()
+	pending := t.windows[key]
+	delete(t.windows, key)
+	if len(pending) > 0 {
+		t.open(key)
+	}
+	t.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), summaryTimeout)
+	defer cancel()
+	t.send(ctx, summarize(key, pending))
+}
+
+// severityRank orders severities so a summary is routed by its most severe
+// message.
+var severityRank = map[string]int{
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+// summarize folds held-back messages into one, carrying the highest
+// severity among them so a critical update still reaches the notifiers
+// routed for critical.
+func summarize(key throttleKey, msgs []Message) Message {
+	if len(msgs) == 1 {
+		return msgs[0]
+	}
+	sum := Message{
+		IncidentID: key.incident,
+		Recipient:  key.recipient,
+		Title:      fmt.Sprintf("Incident #%d: %d updates", key.incident, len(msgs)),
+	}
+	var text strings.Builder
+	for _, m := range msgs {
+		if sum.Severity == "" || severityRank[m.Severity] > severityRank[sum.Severity] {
+			sum.Severity = m.Severity
+		}
+		text.WriteString("- " + m.Title)
+		if m.Text != "" {
+			text.WriteString(": " + m.Text)
+		}
+		text.WriteByte('\n')
+	}
+	sum.Text = strings.TrimSuffix(text.String(), "\n")
+	return sum
+}
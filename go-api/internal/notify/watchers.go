@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"context"
+	"log"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// NotifyWatchers sends msg once per subscriber watching the incident, with the
+// subscriber as the recipient.
+func NotifyWatchers(ctx context.Context, repo store.Repository, d *Dispatcher, msg Message) {
+	if !d.Enabled() {
+		return
+	}
+	watchers, err := repo.ListIncidentWatchers(ctx, msg.IncidentID)
+	if err != nil {
+		log.Printf("notify: listing watchers for incident %d: %v", msg.IncidentID, err)
+		return
+	}
+	for _, w := range watchers {
+		m := msg
+		m.Recipient = w
+		d.Dispatch(ctx, m)
+	}
+}
@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WebhookNotifier POSTs the Message as JSON to an arbitrary endpoint.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: client}
+}
+
+func (n *WebhookNotifier) Name() string { return "webhook" }
+
+func (n *WebhookNotifier) Notify(ctx context.Context, msg Message) error {
+	return postJSON(ctx, n.client, n.url, msg)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Package rootcause suggests a likely root cause for an incident from the
+// error logs around it, without the ML service: recurring error signatures,
+// the first error seen, and which services started failing first.
+package rootcause
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"time"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const maxSignatures = 10
+
+// Signature is a group of error messages that differ only in variable parts
+// such as IDs, numbers and quoted values.
+type Signature struct {
+	Service   string    `json:"service"`
+	Signature string    `json:"signature"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Example   string    `json:"example"`
+}
+
+// ServiceErrors summarises one service's errors in the window. LeadSeconds is
+// how long before the incident's service its errors began; negative when
+// they began after.
+type ServiceErrors struct {
+	Service      string    `json:"service"`
+	ErrorCount   int       `json:"error_count"`
+	FirstErrorAt time.Time `json:"first_error_at"`
+	LeadSeconds  *float64  `json:"lead_seconds,omitempty"`
+}
+
+// Candidate is the suggested root cause.
+type Candidate struct {
+	Service    string  `json:"service"`
+	Signature  string  `json:"signature"`
+	Reason     string  `json:"reason"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Result is the analysis of an incident's window. Signatures holds the most
+// frequent ones; Candidate is nil when there were no errors.
+type Result struct {
+	ErrorCount int             `json:"error_count"`
+	FirstError *store.LogEntry `json:"first_error"`
+	Signatures []Signature     `json:"signatures"`
+	Services   []ServiceErrors `json:"correlated_services"`
+	Candidate  *Candidate      `json:"candidate"`
+}
+
+var (
+	uuidPattern   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	hexPattern    = regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b|\b[0-9a-f]{12,}\b`)
+	quotedPattern = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+	numberPattern = regexp.MustCompile(`\d+(\.\d+)?`)
+)
+
+type sigKey struct{ service, signature string }
+
+// Normalize reduces a message to its signature.
+func Normalize(message string) string {
+	s := uuidPattern.ReplaceAllString(message, "<uuid>")
+	s = hexPattern.ReplaceAllString(s, "<hex>")
+	s = quotedPattern.ReplaceAllString(s, "<str>")
+	return numberPattern.ReplaceAllString(s, "<n>")
+}
+
+// Analyze looks at error logs, oldest first, around an incident in service
+// (empty when the incident names none).
+func Analyze(service string, logs []store.LogEntry) Result {
+	res := Result{ErrorCount: len(logs), Signatures: []Signature{}, Services: []ServiceErrors{}}
+	if len(logs) == 0 {
+		return res
+	}
+	first := logs[0]
+	res.FirstError = &first
+
+	sigs := make(map[sigKey]*Signature)
+	services := make(map[string]*ServiceErrors)
+	for _, l := range logs {
+		k := sigKey{l.Service, Normalize(l.Message)}
+		s, ok := sigs[k]
+		if !ok {
+			s = &Signature{Service: l.Service, Signature: k.signature, FirstSeen: l.Timestamp, Example: l.Message}
+			sigs[k] = s
+		}
+		s.Count++
+		s.LastSeen = l.Timestamp
+
+		svc, ok := services[l.Service]
+		if !ok {
+			svc = &ServiceErrors{Service: l.Service, FirstErrorAt: l.Timestamp}
+			services[l.Service] = svc
+		}
+		svc.ErrorCount++
+	}
+
+	for _, s := range sigs {
+		res.Signatures = append(res.Signatures, *s)
+	}
+	sort.Slice(res.Signatures, func(i, j int) bool {
+		a, b := res.Signatures[i], res.Signatures[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.FirstSeen.Before(b.FirstSeen)
+	})
+	if len(res.Signatures) > maxSignatures {
+		res.Signatures = res.Signatures[:maxSignatures]
+	}
+
+	own, hasOwn := services[service]
+	for _, svc := range services {
+		if hasOwn {
+			lead := math.Round(own.FirstErrorAt.Sub(svc.FirstErrorAt).Seconds())
+			svc.LeadSeconds = &lead
+		}
+		res.Services = append(res.Services, *svc)
+	}
+	sort.Slice(res.Services, func(i, j int) bool {
+		return res.Services[i].FirstErrorAt.Before(res.Services[j].FirstErrorAt)
+	})
+
+	res.Candidate = candidate(service, hasOwn, res, sigs)
+	return res
+}
+
+// candidate prefers the most frequent signature of whichever service began
+// failing first, since errors upstream tend to precede the symptoms they
+// cause. Confidence is that signature's share of all errors in the window.
+func candidate(service string, hasOwn bool, res Result, sigs map[sigKey]*Signature) *Candidate {
+	origin := res.Services[0]
+	var top *Signature
+	for _, s := range sigs {
+		if s.Service != origin.Service {
+			continue
+		}
+		if top == nil || s.Count > top.Count || (s.Count == top.Count && s.FirstSeen.Before(top.FirstSeen)) {
+			top = s
+		}
+	}
+
+	var reason string
+	switch {
+	case !hasOwn:
+		reason = fmt.Sprintf("%s logged the first error in the window", origin.Service)
+	case origin.Service == service:
+		reason = fmt.Sprintf("most frequent error in %s, which failed before any other service", service)
+	default:
+		reason = fmt.Sprintf("%s began failing %.0fs before %s", origin.Service, *origin.LeadSeconds, service)
+	}
+	return &Candidate{
+		Service:    origin.Service,
+		Signature:  top.Signature,
+		Reason:     reason,
+		Confidence: math.Round(float64(top.Count)/float64(res.ErrorCount)*100) / 100,
+	}
+}
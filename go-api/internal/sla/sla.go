@@ -0,0 +1,101 @@
+package sla
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// Target is how long an incident of a given severity may stay
+// unacknowledged and unresolved. A zero duration means no target.
+type Target struct {
+	Ack     time.Duration
+	Resolve time.Duration
+}
+
+// Targets maps incident severity to its SLA target.
+type Targets map[string]Target
+
+// DefaultTargets apply when SLA_TARGETS is unset.
+var DefaultTargets = Targets{
+	"critical": {Ack: 15 * time.Minute, Resolve: 4 * time.Hour},
+	"high":     {Ack: time.Hour, Resolve: 24 * time.Hour},
+	"medium":   {Ack: 4 * time.Hour, Resolve: 72 * time.Hour},
+	"low":      {Ack: 24 * time.Hour, Resolve: 7 * 24 * time.Hour},
+}
+
+// ParseTargets decodes targets from JSON such as
+// {"critical":{"ack":"15m","resolve":"4h"}}. An empty string yields
+// DefaultTargets.
+func ParseTargets(raw string) (Targets, error) {
+	if raw == "" {
+		return DefaultTargets, nil
+	}
+	var spec map[string]struct {
+		Ack     string `json:"ack"`
+		Resolve string `json:"resolve"`
+	}
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		return nil, fmt.Errorf("parse SLA targets: %w", err)
+	}
+	targets := make(Targets, len(spec))
+	for severity, s := range spec {
+		var t Target
+		var err error
+		if s.Ack != "" {
+			if t.Ack, err = time.ParseDuration(s.Ack); err != nil {
+				return nil, fmt.Errorf("%s ack: %w", severity, err)
+			}
+		}
+		if s.Resolve != "" {
+			if t.Resolve, err = time.ParseDuration(s.Resolve); err != nil {
+				return nil, fmt.Errorf("%s resolve: %w", severity, err)
+			}
+		}
+		targets[severity] = t
+	}
+	return targets, nil
+}
+
+// Evaluate reports an incident's SLA position at now. Resolving an incident
+// also counts as acknowledging it. It returns nil when the incident's
+// severity has no target.
+func (t Targets) Evaluate(inc *store.Incident, now time.Time) *store.SLAStatus {
+	target, ok := t[inc.Severity]
+	if !ok || (target.Ack == 0 && target.Resolve == 0) {
+		return nil
+	}
+
+	st := &store.SLAStatus{}
+	if target.Ack > 0 {
+		due := inc.CreatedAt.Add(target.Ack)
+		st.AckDueAt = &due
+		ackedAt := inc.AcknowledgedAt
+		if ackedAt == nil {
+			ackedAt = inc.ResolvedAt
+		}
+		st.AckBreached = breached(due, ackedAt, now)
+	}
+	if target.Resolve > 0 {
+		due := inc.CreatedAt.Add(target.Resolve)
+		st.ResolveDueAt = &due
+		st.ResolveBreached = breached(due, inc.ResolvedAt, now)
+	}
+	return st
+}
+
+// Apply sets the SLA field of each incident.
+func (t Targets) Apply(incidents []store.Incident, now time.Time) {
+	for i := range incidents {
+		incidents[i].SLA = t.Evaluate(&incidents[i], now)
+	}
+}
+
+func breached(due time.Time, doneAt *time.Time, now time.Time) bool {
+	if doneAt != nil {
+		return doneAt.After(due)
+	}
+	return now.After(due)
+}
@@ -0,0 +1,123 @@
+// Package spool keeps log batches on local disk while the database is
+// unreachable so they can be inserted once it is back.
+package spool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// Spool is an append-only file with one JSON-encoded batch of logs per line.
+type Spool struct {
+	path string
+	mu   sync.Mutex
+}
+
+func New(path string) *Spool {
+	return &Spool{path: path}
+}
+
+// Append writes logs as one batch and syncs the file before returning, so an
+// acknowledged batch survives a crash.
+func (s *Spool) Append(logs []store.LogEntry) error {
+	line, err := json.Marshal(logs)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return appendLines(s.path, append(line, '\n'))
+}
+
+// Replay inserts spooled batches oldest first. It stops at the first batch
+// that fails to insert; that batch and everything after it stay spooled for
+// the next attempt. A line that is not a valid batch is moved to the
+// quarantine file (the spool path plus ".corrupt") so it cannot hold up the
+// batches behind it. It returns how many batches were inserted and how many
+// were quarantined.
+func (s *Spool) Replay(ctx context.Context, insert func(context.Context, []store.LogEntry) error) (replayed, quarantined int, err error) {
+	// Move the spool aside so appends during the replay go to a fresh file.
+	// A replay file left by a crash is picked up first.
+	replayPath := s.path + ".replay"
+	s.mu.Lock()
+	if _, err := os.Stat(replayPath); errors.Is(err, fs.ErrNotExist) {
+		if err := os.Rename(s.path, replayPath); err != nil {
+			s.mu.Unlock()
+			if errors.Is(err, fs.ErrNotExist) {
+				return 0, 0, nil
+			}
+			return 0, 0, err
+		}
+	}
+	s.mu.Unlock()
+
+	data, err := os.ReadFile(replayPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	for len(data) > 0 {
+		line, rest, _ := bytes.Cut(data, []byte("\n"))
+		if len(bytes.TrimSpace(line)) > 0 {
+			var logs []store.LogEntry
+			if err := json.Unmarshal(line, &logs); err != nil {
+				// Usually a torn write from a crash. Anything already
+				// inserted must not stay in the replay file, or the next
+				// attempt would insert it again.
+				if qerr := appendLines(s.path+".corrupt", append(bytes.Clone(line), '\n')); qerr != nil {
+					return replayed, quarantined, s.restore(replayPath, data, fmt.Errorf("quarantining corrupt spool batch: %w", qerr))
+				}
+				quarantined++
+				data = rest
+				continue
+			}
+			if err := insert(ctx, logs); err != nil {
+				return replayed, quarantined, s.restore(replayPath, data, err)
+			}
+			replayed++
+		}
+		data = rest
+	}
+	return replayed, quarantined, os.Remove(replayPath)
+}
+
+// restore puts the batches that were not replayed back in front of anything
+// spooled in the meantime.
+func (s *Spool) restore(replayPath string, remaining []byte, cause error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	newer, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.Join(cause, err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(remaining, newer...), 0o600); err != nil {
+		return errors.Join(cause, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return errors.Join(cause, err)
+	}
+	return errors.Join(cause, os.Remove(replayPath))
+}
+
+func appendLines(path string, lines []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(lines)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
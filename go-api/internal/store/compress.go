@@ -0,0 +1,83 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// compressedPreviewLen is how much of a compressed message stays readable
+// in the message column for SQL consumers such as the ML service.
+const compressedPreviewLen = 512
+
+// logColumns are the columns scanLog reads.
+const logColumns = `id, timestamp, service, level, message, metadata, message_compressed, message_gz`
+
+// compressMessage gzips message when it is at least threshold bytes long and
+// compression actually saves space. It returns the preview to keep in the
+// message column alongside the compressed bytes, or ok false to store the
+// message as is.
+func compressMessage(message string, threshold int) (preview string, gz []byte, ok bool) {
+	if threshold <= 0 || len(message) < threshold {
+		return "", nil, false
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(zw, message); err != nil {
+		return "", nil, false
+	}
+	if err := zw.Close(); err != nil {
+		return "", nil, false
+	}
+	// The preview is stored too, so it counts against the savings.
+	preview = messagePreview(message)
+	if len(preview)+buf.Len() >= len(message) {
+		return "", nil, false
+	}
+	return preview, buf.Bytes(), true
+}
+
+// messagePreview cuts message to compressedPreviewLen bytes on a rune
+// boundary.
+func messagePreview(message string) string {
+	if len(message) <= compressedPreviewLen {
+		return message
+	}
+	cut := compressedPreviewLen
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return message[:cut]
+}
+
+func decompressMessage(gz []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+	b, err := io.ReadAll(zr)
+	return string(b), err
+}
+
+// scanLog reads a row selected with logColumns, restoring compressed
+// messages to their full text.
+func scanLog(row pgx.Row) (LogEntry, error) {
+	var l LogEntry
+	var compressed bool
+	var gz []byte
+	if err := row.Scan(&l.ID, &l.Timestamp, &l.Service, &l.Level, &l.Message, &l.Metadata, &compressed, &gz); err != nil {
+		return LogEntry{}, err
+	}
+	if compressed {
+		message, err := decompressMessage(gz)
+		if err != nil {
+			return LogEntry{}, err
+		}
+		l.Message = message
+	}
+	return l, nil
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+// benchMessages are long log messages of the kinds LOG_COMPRESS_THRESHOLD
+// is meant for.
+var benchMessages = []struct{ name, message string }{
+	{"stacktrace", "panic: runtime error: invalid memory address or nil pointer dereference\n" + strings.Repeat(
+		"goroutine 1 [running]:\nmain.(*Handler).IngestLogs(0xc000112000, {0x1234, 0xc0001a2000})\n\t/app/cmd/server/http_handler.go:212 +0x1a5\n", 12)},
+	{"json", `request failed: body=` + strings.Repeat(`{"user_id":4821,"items":[{"sku":"A-1001","qty":2},{"sku":"B-2002","qty":1}],"region":"eu-west-1"},`, 10)},
+	{"sql", "ERROR: duplicate key value violates unique constraint \"orders_pkey\" (SQLSTATE 23505) while executing INSERT INTO orders (id, customer_id, total, created_at) VALUES ($1, $2, $3, $4) " + strings.Repeat("with retry ", 20)},
+}
+
+func TestCompressMessageSavesSpace(t *testing.T) {
+	for _, m := range benchMessages {
+		preview, gz, ok := compressMessage(m.message, 256)
+		if !ok {
+			if len(m.message) <= compressedPreviewLen {
+				continue
+			}
+			t.Errorf("%s: %d-byte message not compressed", m.name, len(m.message))
+			continue
+		}
+		if stored := len(preview) + len(gz); stored >= len(m.message) {
+			t.Errorf("%s: compressed to %d bytes stored, raw is %d", m.name, stored, len(m.message))
+		}
+		if got, err := decompressMessage(gz); err != nil || got != m.message {
+			t.Errorf("%s: round trip = %.40q, %v", m.name, got, err)
+		}
+	}
+}
+
+// BenchmarkCompressMessage times compressing and decompressing each kind of
+// message at a 256-byte threshold and reports the bytes stored per message
+// (preview plus gzip, or the raw message when compression doesn't pay off)
+// against the raw size.
+func BenchmarkCompressMessage(b *testing.B) {
+	for _, m := range benchMessages {
+		preview, gz, ok := compressMessage(m.message, 256)
+		stored := len(m.message)
+		if ok {
+			stored = len(preview) + len(gz)
+		}
+
+		b.Run("compress/"+m.name, func(b *testing.B) {
+			b.SetBytes(int64(len(m.message)))
+			for range b.N {
+				compressMessage(m.message, 256)
+			}
+			b.ReportMetric(float64(len(m.message)), "raw-B/msg")
+			b.ReportMetric(float64(stored), "stored-B/msg")
+			b.ReportMetric(100*float64(len(m.message)-stored)/float64(len(m.message)), "%saved")
+		})
+		if !ok {
+			continue
+		}
+		b.Run("decompress/"+m.name, func(b *testing.B) {
+			b.SetBytes(int64(len(m.message)))
+			for range b.N {
+				if _, err := decompressMessage(gz); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
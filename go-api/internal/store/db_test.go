@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testPool connects to TEST_DATABASE_URL with a fresh schema first on the
+// search path, dropped when the test ends. Tests that need a database are
+// skipped when it is unset.
+func testPool(tb testing.TB) *pgxpool.Pool {
+	tb.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		tb.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		tb.Fatalf("connect: %v", err)
+	}
+	defer admin.Close(ctx)
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, `CREATE SCHEMA `+schema); err != nil {
+		tb.Fatalf("create schema: %v", err)
+	}
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		tb.Fatalf("parse TEST_DATABASE_URL: %v", err)
+	}
+	// public stays on the path for extensions installed there.
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		tb.Fatalf("connect: %v", err)
+	}
+	tb.Cleanup(func() {
+		pool.Close()
+		conn, err := pgx.Connect(context.Background(), url)
+		if err != nil {
+			tb.Logf("drop schema %s: %v", schema, err)
+			return
+		}
+		defer conn.Close(context.Background())
+		if _, err := conn.Exec(context.Background(), `DROP SCHEMA `+schema+` CASCADE`); err != nil {
+			tb.Logf("drop schema %s: %v", schema, err)
+		}
+	})
+	return pool
+}
+
+// testRepo is a repository over testPool with the schema migrated.
+func testRepo(tb testing.TB, opts Options) Repository {
+	tb.Helper()
+	pool := testPool(tb)
+	if err := RunMigrations(context.Background(), pool, 0); err != nil {
+		tb.Fatalf("migrate: %v", err)
+	}
+	return NewRepository(pool, opts)
+}
+
+// testLogs builds n error logs spread over a few services and messages.
+func testLogs(n int) []LogEntry {
+	now := time.Now().UTC()
+	logs := make([]LogEntry, n)
+	for i := range logs {
+		logs[i] = LogEntry{
+			Timestamp: now.Add(-time.Duration(i) * time.Millisecond),
+			Service:   fmt.Sprintf("svc-%d", i%5),
+			Level:     "error",
+			Message:   fmt.Sprintf("request %d failed: upstream timeout after %dms", i, 100+i%900),
+			Metadata:  []byte(`{"region":"eu-west-1"}`),
+		}
+	}
+	return logs
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type ErrorBurst struct {
+	Service    string
+	ErrorCount int
+	FirstSeen  time.Time
+	LastSeen   time.Time
+}
+
+// ErrorBursts returns every service that logged at least threshold
+// error-level entries in [since, until).
+func (r *repository) ErrorBursts(ctx context.Context, since, until time.Time, threshold int) ([]ErrorBurst, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT service, COUNT(*), MIN(timestamp), MAX(timestamp)
+FROM logs
+WHERE timestamp >= $1
+  AND timestamp < $2
+  AND level = ANY($3)
+GROUP BY service
+HAVING COUNT(*) >= $4
+ORDER BY COUNT(*) DESC
+`, since, until, ErrorLevels, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []ErrorBurst
+	for rows.Next() {
+		var b ErrorBurst
+		if err := rows.Scan(&b.Service, &b.ErrorCount, &b.FirstSeen, &b.LastSeen); err != nil {
+			return nil, err
+		}
+		res = append(res, b)
+	}
+	return res, rows.Err()
+}
+
+// FindOpenAutoIncident reads the primary: detection decides from it whether
+// to open a new incident, and a lagging replica would let it open a
+// duplicate.
+func (r *repository) FindOpenAutoIncident(ctx context.Context, service string) (*Incident, error) {
+	inc, err := scanIncident(r.pool.QueryRow(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE service = $1
+  AND auto_created
+  AND status <> 'resolved'
+ORDER BY created_at DESC
+LIMIT 1
+`, service))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inc, nil
+}
+
+// RecordIncidentOccurrence bumps an incident's occurrence count and moves its
+// last_seen_at forward to lastSeen.
+func (r *repository) RecordIncidentOccurrence(ctx context.Context, id int64, lastSeen time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+UPDATE incidents
+SET occurrence_count = occurrence_count + 1,
+    last_seen_at = GREATEST(COALESCE(last_seen_at, $2), $2),
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = $1
+`, id, lastSeen)
+	return err
+}
+
+// LogGroup is a set of identical error logs from one service.
+type LogGroup struct {
+	Level     string    `json:"level"`
+	Message   string    `json:"message"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// ErrorLogGroups groups a service's error-level logs in [since, until) by
+// level and message, largest group first. A compressed message keeps only
+// a preview in the message column, so its compressed bytes are part of the
+// key too: messages that differ past the preview stay apart, and identical
+// ones compress identically.
+func (r *repository) ErrorLogGroups(ctx context.Context, service string, since, until time.Time, limit int) ([]LogGroup, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT level, message, message_gz, COUNT(*), MIN(timestamp), MAX(timestamp)
+FROM logs
+WHERE service = $1
+  AND timestamp >= $2
+  AND timestamp < $3
+  AND level = ANY($4)
+GROUP BY level, message, message_gz
+ORDER BY COUNT(*) DESC, MAX(timestamp) DESC
+LIMIT $5
+`, service, since, until, ErrorLevels, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []LogGroup
+	for rows.Next() {
+		var g LogGroup
+		var gz []byte
+		if err := rows.Scan(&g.Level, &g.Message, &gz, &g.Count, &g.FirstSeen, &g.LastSeen); err != nil {
+			return nil, err
+		}
+		if gz != nil {
+			if g.Message, err = decompressMessage(gz); err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, g)
+	}
+	return res, rows.Err()
+}
+
+// ListErrorLogs returns error-level logs from every service in
+// [since, until), oldest first.
+func (r *repository) ListErrorLogs(ctx context.Context, since, until time.Time, limit int) ([]LogEntry, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT `+logColumns+`
+FROM logs
+WHERE timestamp >= $1
+  AND timestamp < $2
+  AND level = ANY($3)
+ORDER BY timestamp, id
+LIMIT $4
+`, since, until, ErrorLevels, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []LogEntry
+	for rows.Next() {
+		l, err := scanLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, l)
+	}
+	return res, rows.Err()
+}
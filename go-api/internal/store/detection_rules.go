@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DetectionRule overrides the global burst-detection window and threshold
+// for one service. A zero WindowSeconds or Threshold keeps the global value.
+type DetectionRule struct {
+	Service       string    `json:"service"`
+	WindowSeconds int       `json:"window_seconds"`
+	Threshold     int       `json:"threshold"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (r *repository) UpsertDetectionRule(ctx context.Context, rule *DetectionRule) error {
+	return r.pool.QueryRow(ctx, `
+INSERT INTO detection_rules (service, window_seconds, threshold)
+VALUES ($1, $2, $3)
+ON CONFLICT (service) DO UPDATE
+SET window_seconds = EXCLUDED.window_seconds,
+    threshold = EXCLUDED.threshold,
+    updated_at = NOW()
+RETURNING updated_at
+`, rule.Service, rule.WindowSeconds, rule.Threshold).Scan(&rule.UpdatedAt)
+}
+
+func (r *repository) ListDetectionRules(ctx context.Context) ([]DetectionRule, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT service, window_seconds, threshold, updated_at
+FROM detection_rules
+ORDER BY service
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []DetectionRule
+	for rows.Next() {
+		var rule DetectionRule
+		if err := rows.Scan(&rule.Service, &rule.WindowSeconds, &rule.Threshold, &rule.UpdatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, rule)
+	}
+	return res, rows.Err()
+}
+
+func (r *repository) DeleteDetectionRule(ctx context.Context, service string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM detection_rules WHERE service = $1`, service)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ServiceErrorBurst is ErrorBursts for a single service. It returns
+// ErrNotFound when the service stayed below threshold.
+func (r *repository) ServiceErrorBurst(ctx context.Context, service string, since, until time.Time, threshold int) (*ErrorBurst, error) {
+	b := ErrorBurst{Service: service}
+	err := r.pool.QueryRow(ctx, `
+SELECT COUNT(*), MIN(timestamp), MAX(timestamp)
+FROM logs
+WHERE service = $1
+  AND timestamp >= $2
+  AND timestamp < $3
+  AND level = ANY($4)
+HAVING COUNT(*) >= $5
+`, service, since, until, ErrorLevels, threshold).Scan(&b.ErrorCount, &b.FirstSeen, &b.LastSeen)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
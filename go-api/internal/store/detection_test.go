@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorLogGroupsCompressedMessages(t *testing.T) {
+	repo := testRepo(t, Options{CompressMessagesOver: 256})
+	ctx := context.Background()
+
+	// Two messages that share far more than the preview, each logged a
+	// different number of times, and a short one stored as is.
+	prefix := strings.Repeat("stack frame ", 100)
+	long1, long2 := prefix+"caused by: disk full", prefix+"caused by: connection reset"
+	now := time.Now().UTC()
+	var logs []LogEntry
+	for i, msg := range []string{long1, long1, long1, long2, long2, "timeout"} {
+		logs = append(logs, LogEntry{Timestamp: now.Add(-time.Duration(i) * time.Second), Service: "api", Level: "error", Message: msg, Metadata: []byte(`{}`)})
+	}
+	if _, err := repo.InsertLogs(ctx, logs); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := repo.ErrorLogGroups(ctx, "api", now.Add(-time.Minute), now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		message string
+		count   int
+	}{{long1, 3}, {long2, 2}, {"timeout", 1}}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(want))
+	}
+	for i, w := range want {
+		if groups[i].Message != w.message || groups[i].Count != w.count {
+			t.Errorf("group %d: %d x %.40q..., want %d x %.40q...", i, groups[i].Count, groups[i].Message, w.count, w.message)
+		}
+	}
+}
+
+// BenchmarkErrorLogGroups groups an hour of one service's error logs, with
+// and without message compression.
+func BenchmarkErrorLogGroups(b *testing.B) {
+	for _, threshold := range []int{0, 256} {
+		b.Run(fmt.Sprintf("compress=%d", threshold), func(b *testing.B) {
+			repo := testRepo(b, Options{CompressMessagesOver: threshold})
+			ctx := context.Background()
+
+			const n = 50000
+			now := time.Now().UTC()
+			logs := make([]LogEntry, n)
+			for i := range logs {
+				msg := fmt.Sprintf("error %d: upstream unavailable", i%40)
+				if i%4 == 0 {
+					msg = strings.Repeat("stack frame ", 60) + msg
+				}
+				logs[i] = LogEntry{
+					Timestamp: now.Add(-time.Duration(i%3600) * time.Second),
+					Service:   fmt.Sprintf("svc-%d", i%5),
+					Level:     "error",
+					Message:   msg,
+					Metadata:  []byte(`{}`),
+				}
+			}
+			if _, err := InsertLogsConcurrently(ctx, repo, logs, 5000, 4); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for range b.N {
+				if _, err := repo.ErrorLogGroups(ctx, "svc-0", now.Add(-time.Hour), now.Add(time.Second), 20); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
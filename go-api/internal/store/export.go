@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// IncidentFilter selects incidents created in [Since, Until), optionally for
+// one service.
+type IncidentFilter struct {
+	Since   time.Time
+	Until   time.Time
+	Service string
+}
+
+// StreamIncidents calls fn for each matching incident, oldest first, reading
+// rows as they arrive instead of loading the whole result. It stops at the
+// first error fn returns.
+func (r *repository) StreamIncidents(ctx context.Context, f IncidentFilter, fn func(Incident) error) error {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE created_at >= $1
+  AND created_at < $2
+  AND ($3 = '' OR service = $3)
+ORDER BY created_at, id
+`, f.Since, f.Until, f.Service)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(inc); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
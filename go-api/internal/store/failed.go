@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// FailedIngestion is a batch of logs whose insert failed, kept so it can be
+// replayed once the database recovers.
+type FailedIngestion struct {
+	ID        int64           `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Error     string          `json:"error"`
+	Attempts  int             `json:"attempts"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// SaveFailedIngestion dead-letters logs as a JSON array alongside the error
+// that caused the insert to fail.
+func (r *repository) SaveFailedIngestion(ctx context.Context, logs []LogEntry, cause error) error {
+	payload, err := json.Marshal(logs)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `
+INSERT INTO failed_ingestions (error, payload)
+VALUES ($1, $2)
+`, cause.Error(), payload)
+	return err
+}
+
+// ListFailedIngestions returns the oldest dead-lettered batches first.
+func (r *repository) ListFailedIngestions(ctx context.Context, limit int) ([]FailedIngestion, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT id, created_at, error, attempts, payload
+FROM failed_ingestions
+ORDER BY id
+LIMIT $1
+`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []FailedIngestion
+	for rows.Next() {
+		var f FailedIngestion
+		if err := rows.Scan(&f.ID, &f.CreatedAt, &f.Error, &f.Attempts, &f.Payload); err != nil {
+			return nil, err
+		}
+		res = append(res, f)
+	}
+	return res, rows.Err()
+}
+
+func (r *repository) DeleteFailedIngestion(ctx context.Context, id int64) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM failed_ingestions WHERE id = $1`, id)
+	return err
+}
+
+// MarkFailedIngestionRetry records another unsuccessful replay attempt.
+func (r *repository) MarkFailedIngestionRetry(ctx context.Context, id int64, cause error) error {
+	_, err := r.pool.Exec(ctx, `
+UPDATE failed_ingestions
+SET attempts = attempts + 1,
+    error = $2
+WHERE id = $1
+`, id, cause.Error())
+	return err
+}
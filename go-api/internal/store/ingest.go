@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// LogChunkError describes a chunk of a concurrent insert that failed.
+type LogChunkError struct {
+	Offset int
+	Count  int
+	Err    error
+}
+
+// LogRowError is a single log the database rejected, such as for a
+// constraint violation. Index is its position in the inserted slice.
+type LogRowError struct {
+	Index int
+	Err   error
+}
+
+// PartialInsertError reports what an insert could not store. Failed lists
+// whole chunks of a concurrent insert that failed, typically because the
+// database was unreachable; Rows lists individual logs that were rejected
+// while the rest of their batch was stored. Whatever succeeded stays
+// committed.
+type PartialInsertError struct {
+	Inserted int
+	Failed   []LogChunkError
+	Rows     []LogRowError
+}
+
+func (e *PartialInsertError) Error() string {
+	failed := len(e.Rows)
+	for _, f := range e.Failed {
+		failed += f.Count
+	}
+	var first error
+	switch {
+	case len(e.Failed) > 0:
+		first = e.Failed[0].Err
+	case len(e.Rows) > 0:
+		first = e.Rows[0].Err
+	}
+	return fmt.Sprintf("inserted %d logs, %d failed (%d chunks, %d rows): %v", e.Inserted, failed, len(e.Failed), len(e.Rows), first)
+}
+
+// InsertLogsConcurrently splits logs into chunks of chunkSize and inserts up
+// to parallelism chunks at once, each on its own pool connection. IDs are
+// returned in input order, with zero for logs that were not stored,
+// alongside a *PartialInsertError when any chunk or row failed. Batches no
+// larger than chunkSize use a single InsertLogs call.
+func InsertLogsConcurrently(ctx context.Context, repo Repository, logs []LogEntry, chunkSize, parallelism int) ([]int64, error) {
+	if chunkSize <= 0 || len(logs) <= chunkSize {
+		return repo.InsertLogs(ctx, logs)
+	}
+
+	ids := make([]int64, len(logs))
+	chunks := (len(logs) + chunkSize - 1) / chunkSize
+	chunkErrs := make([]error, chunks)
+
+	var g errgroup.Group
+	g.SetLimit(max(parallelism, 1))
+	for i := range chunks {
+		start := i * chunkSize
+		end := min(start+chunkSize, len(logs))
+		g.Go(func() error {
+			chunkIDs, err := repo.InsertLogs(ctx, logs[start:end])
+			if chunkIDs != nil {
+				copy(ids[start:end], chunkIDs)
+			}
+			chunkErrs[i] = err
+			return nil
+		})
+	}
+	g.Wait()
+
+	partial := &PartialInsertError{}
+	for i, err := range chunkErrs {
+		start := i * chunkSize
+		count := min(chunkSize, len(logs)-start)
+		rowsErr, ok := err.(*PartialInsertError)
+		switch {
+		case err == nil:
+			partial.Inserted += count
+		case ok:
+			partial.Inserted += rowsErr.Inserted
+			for _, row := range rowsErr.Rows {
+				partial.Rows = append(partial.Rows, LogRowError{Index: start + row.Index, Err: row.Err})
+			}
+		default:
+			partial.Failed = append(partial.Failed, LogChunkError{Offset: start, Count: count, Err: err})
+		}
+	}
+	if len(partial.Failed) > 0 || len(partial.Rows) > 0 {
+		return ids, partial
+	}
+	return ids, nil
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// BenchmarkInsertLogs compares one InsertLogs call for a large batch with
+// InsertLogsConcurrently at a few INSERT_CHUNK_SIZE / INSERT_PARALLELISM
+// settings.
+func BenchmarkInsertLogs(b *testing.B) {
+	repo := testRepo(b, Options{})
+	ctx := context.Background()
+	const batch = 10000
+	logs := testLogs(batch)
+
+	cases := []struct{ chunk, parallelism int }{
+		{0, 1},
+		{1000, 1},
+		{1000, 4},
+		{2500, 4},
+		{500, 8},
+	}
+	for _, tc := range cases {
+		name := "single"
+		if tc.chunk > 0 {
+			name = fmt.Sprintf("chunk=%d/parallel=%d", tc.chunk, tc.parallelism)
+		}
+		b.Run(name, func(b *testing.B) {
+			for range b.N {
+				if _, err := InsertLogsConcurrently(ctx, repo, logs, tc.chunk, tc.parallelism); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(batch*b.N)/b.Elapsed().Seconds(), "logs/s")
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Job statuses. A job is queued until a slot frees up, running while its
+// work runs, and then done, failed or canceled. A job left queued or
+// running by an instance that stopped heartbeating is marked interrupted.
+const (
+	JobQueued      = "queued"
+	JobRunning     = "running"
+	JobDone        = "done"
+	JobFailed      = "failed"
+	JobCanceled    = "canceled"
+	JobInterrupted = "interrupted"
+)
+
+// Job is an asynchronous operation started through the API. Total,
+// Succeeded and Failed count the items it works through; Errors holds the
+// first item failures, and Error why the job as a whole failed. Owner is
+// the instance running it, which refreshes HeartbeatAt with every save. At
+// most one Exclusive job of a kind is unfinished at a time.
+type Job struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Status      string          `json:"status"`
+	CreatedBy   string          `json:"created_by"`
+	Owner       string          `json:"owner"`
+	Exclusive   bool            `json:"exclusive"`
+	HeartbeatAt *time.Time      `json:"heartbeat_at,omitempty"`
+	Total       int             `json:"total"`
+	Succeeded   int             `json:"succeeded"`
+	Failed      int             `json:"failed"`
+	Errors      []string        `json:"errors,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+const jobColumns = `id, kind, status, created_by, owner, exclusive, heartbeat_at, total, succeeded, failed, errors, error, result, created_at, started_at, finished_at`
+
+// CreateJob records a new job. It returns ErrConflict for an exclusive job
+// while another of its kind is unfinished, on any instance.
+func (r *repository) CreateJob(ctx context.Context, j *Job) error {
+	err := r.pool.QueryRow(ctx, `
+INSERT INTO jobs (id, kind, status, created_by, owner, exclusive, heartbeat_at)
+VALUES ($1, $2, $3, $4, $5, $6, NOW())
+RETURNING created_at, heartbeat_at
+`, j.ID, j.Kind, j.Status, j.CreatedBy, j.Owner, j.Exclusive).Scan(&j.CreatedAt, &j.HeartbeatAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrConflict
+	}
+	return err
+}
+
+// SaveJob writes a job's status, progress and outcome and refreshes its
+// heartbeat. It returns ErrNotFound once the job has been marked
+// interrupted, so a job given up on stays that way.
+func (r *repository) SaveJob(ctx context.Context, j *Job) error {
+	err := r.pool.QueryRow(ctx, `
+UPDATE jobs
+SET status = $2,
+    total = $3,
+    succeeded = $4,
+    failed = $5,
+    errors = COALESCE($6::text[], '{}'),
+    error = $7,
+    result = $8,
+    started_at = $9,
+    finished_at = $10,
+    heartbeat_at = NOW()
+WHERE id = $1 AND status <> 'interrupted'
+RETURNING heartbeat_at
+`, j.ID, j.Status, j.Total, j.Succeeded, j.Failed, j.Errors, j.Error, j.Result, j.StartedAt, j.FinishedAt).Scan(&j.HeartbeatAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *repository) GetJob(ctx context.Context, id string) (*Job, error) {
+	var j Job
+	var result []byte
+	err := r.pool.QueryRow(ctx, `
+SELECT `+jobColumns+`
+FROM jobs
+WHERE id = $1
+`, id).Scan(&j.ID, &j.Kind, &j.Status, &j.CreatedBy, &j.Owner, &j.Exclusive, &j.HeartbeatAt, &j.Total, &j.Succeeded, &j.Failed, &j.Errors, &j.Error, &result, &j.CreatedAt, &j.StartedAt, &j.FinishedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	j.Result = result
+	return &j, nil
+}
+
+// InterruptJobs marks jobs left queued or running whose owner has not
+// heartbeated since staleBefore as interrupted; their work cannot be
+// resumed. Jobs other instances are still running are left alone. It
+// returns how many were marked.
+func (r *repository) InterruptJobs(ctx context.Context, staleBefore time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+UPDATE jobs
+SET status = 'interrupted',
+    finished_at = NOW()
+WHERE status IN ('queued', 'running')
+  AND COALESCE(heartbeat_at, created_at) < $1
+`, staleBefore)
+	return tag.RowsAffected(), err
+}
+
+// DeleteFinishedJobs deletes jobs that finished before before, returning
+// how many.
+func (r *repository) DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+DELETE FROM jobs
+WHERE status NOT IN ('queued', 'running') AND finished_at < $1
+`, before)
+	return tag.RowsAffected(), err
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type IncidentLink struct {
+	ID         int64     `json:"id"`
+	IncidentID int64     `json:"incident_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Label      string    `json:"label"`
+	URL        string    `json:"url"`
+	Kind       string    `json:"kind"`
+}
+
+func (r *repository) CreateIncidentLink(ctx context.Context, link *IncidentLink) error {
+	return r.pool.QueryRow(ctx, `
+INSERT INTO incident_links (incident_id, label, url, kind)
+VALUES ($1, $2, $3, $4)
+RETURNING id, created_at
+`, link.IncidentID, link.Label, link.URL, link.Kind).Scan(&link.ID, &link.CreatedAt)
+}
+
+func (r *repository) ListIncidentLinks(ctx context.Context, incidentID int64) ([]IncidentLink, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT id, incident_id, created_at, label, url, kind
+FROM incident_links
+WHERE incident_id = $1
+ORDER BY created_at, id
+`, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []IncidentLink
+	for rows.Next() {
+		var l IncidentLink
+		if err := rows.Scan(&l.ID, &l.IncidentID, &l.CreatedAt, &l.Label, &l.URL, &l.Kind); err != nil {
+			return nil, err
+		}
+		res = append(res, l)
+	}
+	return res, rows.Err()
+}
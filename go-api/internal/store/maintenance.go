@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// MaintenanceWindow suppresses auto-created incidents for a service (or for
+// every service when Service is nil) between StartsAt and EndsAt.
+type MaintenanceWindow struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Service   *string   `json:"service"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Reason    string    `json:"reason"`
+}
+
+func (r *repository) CreateMaintenanceWindow(ctx context.Context, w *MaintenanceWindow) error {
+	return r.pool.QueryRow(ctx, `
+INSERT INTO maintenance_windows (service, starts_at, ends_at, reason)
+VALUES ($1, $2, $3, $4)
+RETURNING id, created_at
+`, w.Service, w.StartsAt, w.EndsAt, w.Reason).Scan(&w.ID, &w.CreatedAt)
+}
+
+// ListMaintenanceWindows returns windows that have not yet ended at the given
+// time, or all windows when activeAt is nil.
+func (r *repository) ListMaintenanceWindows(ctx context.Context, activeAt *time.Time) ([]MaintenanceWindow, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT id, created_at, service, starts_at, ends_at, reason
+FROM maintenance_windows
+WHERE $1::timestamptz IS NULL OR ends_at > $1
+ORDER BY starts_at DESC
+`, activeAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []MaintenanceWindow
+	for rows.Next() {
+		var w MaintenanceWindow
+		if err := rows.Scan(&w.ID, &w.CreatedAt, &w.Service, &w.StartsAt, &w.EndsAt, &w.Reason); err != nil {
+			return nil, err
+		}
+		res = append(res, w)
+	}
+	return res, rows.Err()
+}
+
+func (r *repository) InMaintenance(ctx context.Context, service string, at time.Time) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx, `
+SELECT EXISTS (
+    SELECT 1 FROM maintenance_windows
+    WHERE (service IS NULL OR service = $1)
+      AND starts_at <= $2 AND ends_at > $2
+)
+`, service, at).Scan(&exists)
+	return exists, err
+}
+
+// TagMaintenanceIncidents adds a "maintenance" tag to unresolved incidents
+// that were created inside a maintenance window covering their service, and
+// returns the IDs it tagged.
+func (r *repository) TagMaintenanceIncidents(ctx context.Context) ([]int64, error) {
+	rows, err := r.pool.Query(ctx, `
+UPDATE incidents i
+SET tags = array_append(i.tags, 'maintenance'),
+    updated_at = NOW(),
+    version = i.version + 1
+WHERE i.status <> 'resolved'
+  AND NOT ('maintenance' = ANY(i.tags))
+  AND EXISTS (
+      SELECT 1 FROM maintenance_windows m
+      WHERE (m.service IS NULL OR m.service = i.service)
+        AND i.created_at >= m.starts_at
+        AND i.created_at < m.ends_at
+  )
+RETURNING i.id
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
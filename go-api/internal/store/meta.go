@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// DistinctLogServices lists the services that have logged since the given
+// time, or ever when since is nil.
+func (r *repository) DistinctLogServices(ctx context.Context, since *time.Time) ([]string, error) {
+	return r.distinctLogValues(ctx, `
+SELECT DISTINCT service FROM logs
+WHERE $1::timestamptz IS NULL OR timestamp >= $1
+ORDER BY service
+`, since)
+}
+
+// DistinctLogLevels lists the levels logged since the given time, or ever
+// when since is nil.
+func (r *repository) DistinctLogLevels(ctx context.Context, since *time.Time) ([]string, error) {
+	return r.distinctLogValues(ctx, `
+SELECT DISTINCT level FROM logs
+WHERE $1::timestamptz IS NULL OR timestamp >= $1
+ORDER BY level
+`, since)
+}
+
+func (r *repository) distinctLogValues(ctx context.Context, query string, since *time.Time) ([]string, error) {
+	rows, err := r.reader().Query(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, rows.Err()
+}
+
+// MetadataKey describes one top-level metadata key seen in a sample of a
+// service's logs: how many sampled logs carried it and with which JSON
+// types.
+type MetadataKey struct {
+	Key       string           `json:"key"`
+	Count     int64            `json:"count"`
+	Frequency float64          `json:"frequency"`
+	Types     map[string]int64 `json:"types"`
+}
+
+// ServiceMetadataKeys samples the service's most recent logs, up to limit,
+// and reports the top-level metadata keys they carry, along with the number
+// of logs sampled.
+func (r *repository) ServiceMetadataKeys(ctx context.Context, service string, limit int) ([]MetadataKey, int64, error) {
+	rows, err := r.reader().Query(ctx, `
+WITH sample AS (
+    SELECT metadata FROM logs
+    WHERE service = $1
+    ORDER BY timestamp DESC
+    LIMIT $2
+)
+SELECT kv.key, jsonb_typeof(kv.value), COUNT(*), (SELECT COUNT(*) FROM sample)
+FROM sample
+CROSS JOIN LATERAL jsonb_each(CASE WHEN jsonb_typeof(sample.metadata) = 'object' THEN sample.metadata ELSE '{}'::jsonb END) AS kv
+GROUP BY kv.key, jsonb_typeof(kv.value)
+ORDER BY kv.key
+`, service, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var res []MetadataKey
+	var sampled int64
+	for rows.Next() {
+		var key, typ string
+		var n int64
+		if err := rows.Scan(&key, &typ, &n, &sampled); err != nil {
+			return nil, 0, err
+		}
+		if len(res) == 0 || res[len(res)-1].Key != key {
+			res = append(res, MetadataKey{Key: key, Types: map[string]int64{}})
+		}
+		k := &res[len(res)-1]
+		k.Count += n
+		k.Types[typ] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	for i := range res {
+		if sampled > 0 {
+			res[i].Frequency = float64(res[i].Count) / float64(sampled)
+		}
+	}
+	return res, sampled, nil
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var metadataKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,50}$`)
+
+// ValidMetadataKey reports whether key can be promoted to an indexed column.
+// Keys are interpolated into DDL, so only lowercase identifiers are allowed.
+func ValidMetadataKey(key string) bool {
+	return metadataKeyPattern.MatchString(key)
+}
+
+func metadataColumn(key string) string {
+	return "meta_" + key
+}
+
+// IndexMetadataKeys extracts each metadata key into a stored generated column
+// on logs with a B-tree index, so equality filters on it avoid scanning the
+// JSONB. Adding a column rewrites the logs table once; columns for keys later
+// removed from the list are left in place.
+func IndexMetadataKeys(ctx context.Context, pool *pgxpool.Pool, keys []string) error {
+	for _, key := range keys {
+		if !ValidMetadataKey(key) {
+			return fmt.Errorf("invalid metadata key %q", key)
+		}
+		col := metadataColumn(key)
+		if _, err := pool.Exec(ctx, fmt.Sprintf(`
+ALTER TABLE logs ADD COLUMN IF NOT EXISTS %[1]s TEXT GENERATED ALWAYS AS (metadata->>'%[2]s') STORED;
+CREATE INDEX IF NOT EXISTS idx_logs_%[1]s ON logs(%[1]s);
+`, col, key)); err != nil {
+			return fmt.Errorf("index metadata key %s: %w", key, err)
+		}
+	}
+	return nil
+}
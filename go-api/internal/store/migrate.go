@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultBackfillBatch is how wide a range of ids a column backfill updates
+// per statement when RunMigrations is given no batch size.
+const DefaultBackfillBatch = 10000
+
+// columnMigration adds a column to a table that may already hold rows from
+// an older schema. With a constant Default and no Backfill the column is
+// added with its default in one step, which Postgres 11+ records in the
+// catalog without touching existing rows. Otherwise the column is added
+// nullable, its default is set, rows that predate it are backfilled in
+// batches, and only then is it made NOT NULL, so no single step fails on
+// existing data or rewrites a large table in one transaction.
+type columnMigration struct {
+	Table   string
+	Column  string
+	Type    string
+	Default string
+	// Backfill is the value given to existing rows; empty means Default,
+	// which must then be a constant.
+	Backfill string
+	NotNull  bool
+}
+
+// columnMigrations are applied in order after the tables exist. Every
+// table here has an id column, which backfills page through.
+var columnMigrations = []columnMigration{
+	{Table: "incidents", Column: "auto_created", Type: "BOOLEAN", Default: "FALSE", NotNull: true},
+	{Table: "incidents", Column: "tags", Type: "TEXT[]", Default: "'{}'", NotNull: true},
+	{Table: "incidents", Column: "occurrence_count", Type: "INTEGER", Default: "1", NotNull: true},
+	{Table: "incidents", Column: "version", Type: "BIGINT", Default: "1", NotNull: true},
+	{
+		Table:    "incidents",
+		Column:   "updated_at",
+		Type:     "TIMESTAMPTZ",
+		Default:  "NOW()",
+		Backfill: "GREATEST(created_at, resolved_at, acknowledged_at, summary_updated_at, last_seen_at)",
+		NotNull:  true,
+	},
+	{Table: "logs", Column: "message_compressed", Type: "BOOLEAN", Default: "FALSE", NotNull: true},
+	{Table: "incident_events", Column: "actor", Type: "TEXT", Default: "'system'", NotNull: true},
+}
+
+// migrateColumn applies m, skipping it once the column exists with the
+// wanted nullability. Each step is idempotent, so a run interrupted midway
+// picks up where it left off.
+func migrateColumn(ctx context.Context, pool *pgxpool.Pool, m columnMigration, batch int) error {
+	var nullable string
+	err := pool.QueryRow(ctx, `
+SELECT is_nullable
+FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2
+`, m.Table, m.Column).Scan(&nullable)
+	exists := err == nil
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return err
+	case !m.NotNull || nullable == "NO":
+		return nil
+	}
+
+	table, column := pgx.Identifier{m.Table}.Sanitize(), pgx.Identifier{m.Column}.Sanitize()
+	if !exists && m.Backfill == "" {
+		add := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s DEFAULT %s`, table, column, m.Type, m.Default)
+		if m.NotNull {
+			add += " NOT NULL"
+		}
+		if _, err := pool.Exec(ctx, add); err != nil {
+			return fmt.Errorf("%s.%s: %w", m.Table, m.Column, err)
+		}
+		return nil
+	}
+
+	steps := []string{
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s`, table, column, m.Type),
+		fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s`, table, column, m.Default),
+	}
+	for _, step := range steps {
+		if _, err := pool.Exec(ctx, step); err != nil {
+			return fmt.Errorf("%s.%s: %w", m.Table, m.Column, err)
+		}
+	}
+
+	backfill := m.Backfill
+	if backfill == "" {
+		backfill = m.Default
+	}
+	// Rows added from here on get the default, so paging through the ids
+	// that exist now covers every NULL, each batch an index range scan.
+	var maxID int64
+	if err := pool.QueryRow(ctx, fmt.Sprintf(`SELECT COALESCE(MAX(id), 0) FROM %s`, table)).Scan(&maxID); err != nil {
+		return fmt.Errorf("%s.%s: %w", m.Table, m.Column, err)
+	}
+	update := fmt.Sprintf(`
+UPDATE %[1]s SET %[2]s = %[3]s
+WHERE id > $1 AND id <= $2 AND %[2]s IS NULL
+`, table, column, backfill)
+	for from := int64(0); from < maxID; from += int64(batch) {
+		if err := backfillBatch(ctx, pool, update, from, from+int64(batch)); err != nil {
+			return fmt.Errorf("%s.%s: backfill: %w", m.Table, m.Column, err)
+		}
+	}
+
+	if m.NotNull {
+		// A validated CHECK lets SET NOT NULL skip its own scan, and
+		// validating one doesn't block writes the way SET NOT NULL does.
+		check := pgx.Identifier{m.Table + "_" + m.Column + "_not_null"}.Sanitize()
+		steps := []string{
+			fmt.Sprintf(`ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s`, table, check),
+			fmt.Sprintf(`ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s IS NOT NULL) NOT VALID`, table, check, column),
+			fmt.Sprintf(`ALTER TABLE %s VALIDATE CONSTRAINT %s`, table, check),
+			fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN %s SET NOT NULL`, table, column),
+			fmt.Sprintf(`ALTER TABLE %s DROP CONSTRAINT %s`, table, check),
+		}
+		for _, step := range steps {
+			if _, err := pool.Exec(ctx, step); err != nil {
+				return fmt.Errorf("%s.%s: %w", m.Table, m.Column, err)
+			}
+		}
+	}
+	return nil
+}
+
+// backfillBatch runs one backfill statement over the ids in (from, to] in
+// its own transaction, attributing any incident audit entries it causes to
+// the migration.
+func backfillBatch(ctx context.Context, pool *pgxpool.Pool, update string, from, to int64) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setAuditActor(ctx, tx, "migration"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, update, from, to); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMigrateColumnPopulatedTable(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+
+	const rows = 2500
+	if _, err := pool.Exec(ctx, `
+CREATE TABLE widgets (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL
+);
+INSERT INTO widgets (created_at)
+SELECT TIMESTAMPTZ '2024-01-01' + n * INTERVAL '1 minute' FROM generate_series(1, 2500) n;
+-- A column an interrupted run left nullable and partly backfilled.
+ALTER TABLE widgets ADD COLUMN score INTEGER;
+UPDATE widgets SET score = 7 WHERE id <= 100;
+`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	migrations := []columnMigration{
+		{Table: "widgets", Column: "flag", Type: "BOOLEAN", Default: "FALSE", NotNull: true},
+		{Table: "widgets", Column: "touched_at", Type: "TIMESTAMPTZ", Default: "NOW()", Backfill: "created_at", NotNull: true},
+		{Table: "widgets", Column: "score", Type: "INTEGER", Default: "1", NotNull: true},
+	}
+	// Twice, to show a finished migration is skipped cleanly.
+	for range 2 {
+		for _, m := range migrations {
+			if err := migrateColumn(ctx, pool, m, 1000); err != nil {
+				t.Fatalf("migrate %s: %v", m.Column, err)
+			}
+		}
+	}
+
+	for _, m := range migrations {
+		var nullable string
+		if err := pool.QueryRow(ctx, `
+SELECT is_nullable FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name = 'widgets' AND column_name = $1
+`, m.Column).Scan(&nullable); err != nil {
+			t.Fatalf("%s: %v", m.Column, err)
+		}
+		if nullable != "NO" {
+			t.Errorf("%s is still nullable", m.Column)
+		}
+	}
+
+	var flagged, backfilled, kept, defaulted int
+	if err := pool.QueryRow(ctx, `
+SELECT
+    COUNT(*) FILTER (WHERE NOT flag),
+    COUNT(*) FILTER (WHERE touched_at = created_at),
+    COUNT(*) FILTER (WHERE score = 7),
+    COUNT(*) FILTER (WHERE score = 1)
+FROM widgets
+`).Scan(&flagged, &backfilled, &kept, &defaulted); err != nil {
+		t.Fatal(err)
+	}
+	if flagged != rows {
+		t.Errorf("flag defaulted on %d rows, want %d", flagged, rows)
+	}
+	if backfilled != rows {
+		t.Errorf("touched_at backfilled from created_at on %d rows, want %d", backfilled, rows)
+	}
+	if kept != 100 || defaulted != rows-100 {
+		t.Errorf("score: %d rows kept 7 and %d got the default, want 100 and %d", kept, defaulted, rows-100)
+	}
+
+	var checks int
+	if err := pool.QueryRow(ctx, `
+SELECT COUNT(*) FROM pg_constraint WHERE conrelid = 'widgets'::regclass AND contype = 'c'
+`).Scan(&checks); err != nil {
+		t.Fatal(err)
+	}
+	if checks != 0 {
+		t.Errorf("%d temporary NOT NULL checks left behind", checks)
+	}
+}
@@ -0,0 +1,317 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Log partition intervals accepted by PartitionLogs and EnsureLogPartitions.
+const (
+	PartitionDaily   = "day"
+	PartitionMonthly = "month"
+)
+
+// partitionStart returns the start of the UTC day or month containing t.
+func partitionStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	if interval == PartitionMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// nextPartition returns the start of the day or month after the one
+// containing t.
+func nextPartition(t time.Time, interval string) time.Time {
+	start := partitionStart(t, interval)
+	if interval == PartitionMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func partitionName(from time.Time, interval string) string {
+	if interval == PartitionMonthly {
+		return "logs_p" + from.Format("200601")
+	}
+	return "logs_p" + from.Format("20060102")
+}
+
+// PartitionLogs converts logs into a table range-partitioned on timestamp,
+// once; it does nothing when logs is already partitioned. The existing table
+// is kept as logs_legacy, attached as the partition for everything up to the
+// end of the current day or month, so no rows are copied; attaching does scan
+// it once to build the new primary key on (id, timestamp). A default
+// partition catches logs beyond the pre-created partitions, including any
+// future-dated legacy logs, which are moved there so one stray timestamp
+// can't stretch logs_legacy past retention.
+func PartitionLogs(ctx context.Context, pool *pgxpool.Pool, interval string) error {
+	partitioned := func(q interface {
+		QueryRow(context.Context, string, ...any) pgx.Row
+	}) (bool, error) {
+		var kind string
+		err := q.QueryRow(ctx, `SELECT relkind::text FROM pg_class WHERE oid = 'logs'::regclass`).Scan(&kind)
+		return kind == "p", err
+	}
+	if done, err := partitioned(pool); done || err != nil {
+		return err
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `LOCK TABLE logs IN ACCESS EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	// Another instance may have converted the table while this one waited.
+	if done, err := partitioned(tx); done || err != nil {
+		return err
+	}
+	var sequence string
+	if err := tx.QueryRow(ctx, `SELECT pg_get_serial_sequence('logs', 'id')`).Scan(&sequence); err != nil {
+		return err
+	}
+
+	var now time.Time
+	if err := tx.QueryRow(ctx, `SELECT NOW()`).Scan(&now); err != nil {
+		return err
+	}
+	boundary := nextPartition(now, interval)
+
+	var indexes []struct{ name, def string }
+	rows, err := tx.Query(ctx, `
+SELECT c.relname, pg_get_indexdef(i.indexrelid)
+FROM pg_index i
+JOIN pg_class c ON c.oid = i.indexrelid
+WHERE i.indrelid = 'logs'::regclass AND NOT i.indisprimary
+`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var ix struct{ name, def string }
+		if err := rows.Scan(&ix.name, &ix.def); err != nil {
+			return err
+		}
+		indexes = append(indexes, ix)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	stmts := []string{
+		`ALTER TABLE logs RENAME TO logs_legacy`,
+		`ALTER TABLE logs_legacy DROP CONSTRAINT IF EXISTS logs_pkey`,
+		`CREATE TABLE logs (LIKE logs_legacy INCLUDING DEFAULTS INCLUDING GENERATED) PARTITION BY RANGE (timestamp)`,
+		`ALTER TABLE logs ADD PRIMARY KEY (id, timestamp)`,
+		`ALTER SEQUENCE ` + sequence + ` OWNED BY logs.id`,
+	}
+	// Index names are schema-wide, so the legacy indexes step aside for the
+	// parent's and are then attached to them as equivalents.
+	for _, ix := range indexes {
+		using := strings.Index(ix.def, " USING ")
+		if using < 0 {
+			return fmt.Errorf("unexpected definition for index %s: %s", ix.name, ix.def)
+		}
+		stmts = append(stmts,
+			fmt.Sprintf(`ALTER INDEX %s RENAME TO %s`, pgx.Identifier{ix.name}.Sanitize(), pgx.Identifier{ix.name + "_legacy"}.Sanitize()),
+			fmt.Sprintf(`CREATE INDEX %s ON logs%s`, pgx.Identifier{ix.name}.Sanitize(), ix.def[using:]),
+		)
+	}
+	stmts = append(stmts, `CREATE TABLE logs_default PARTITION OF logs DEFAULT`)
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+
+	cols, err := storedLogColumns(ctx, tx)
+	if err != nil {
+		return err
+	}
+	stmts = []string{
+		fmt.Sprintf(`
+WITH moved AS (
+    DELETE FROM logs_legacy WHERE timestamp >= '%s' RETURNING %s
+)
+INSERT INTO logs (%[2]s) SELECT %[2]s FROM moved`, boundary.Format(time.RFC3339), cols),
+		fmt.Sprintf(`ALTER TABLE logs ATTACH PARTITION logs_legacy FOR VALUES FROM (MINVALUE) TO ('%s')`, boundary.Format(time.RFC3339)),
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// storedLogColumns lists the columns of logs a row can be copied with, as a
+// comma-separated list: generated columns reject explicit values and are
+// recomputed instead.
+func storedLogColumns(ctx context.Context, tx pgx.Tx) (string, error) {
+	rows, err := tx.Query(ctx, `
+SELECT attname FROM pg_attribute
+WHERE attrelid = 'logs'::regclass AND attnum > 0 AND NOT attisdropped AND attgenerated = ''
+ORDER BY attnum
+`)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return "", err
+		}
+		cols = append(cols, pgx.Identifier{col}.Sanitize())
+	}
+	return strings.Join(cols, ", "), rows.Err()
+}
+
+// logPartition is a bounded partition of logs; the default partition is
+// never listed.
+type logPartition struct {
+	name  string
+	until time.Time
+}
+
+func (r *repository) logPartitions(ctx context.Context) ([]logPartition, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT c.relname, bound.until
+FROM pg_inherits i
+JOIN pg_class c ON c.oid = i.inhrelid
+CROSS JOIN LATERAL (
+    SELECT (regexp_match(pg_get_expr(c.relpartbound, c.oid), 'TO \(''([^'']+)''\)'))[1]::timestamptz AS until
+) bound
+WHERE i.inhparent = 'logs'::regclass AND bound.until IS NOT NULL
+ORDER BY bound.until
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []logPartition
+	for rows.Next() {
+		var p logPartition
+		if err := rows.Scan(&p.name, &p.until); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+// EnsureLogPartitions creates partitions so that logs up to ahead days or
+// months past now each land in their own, continuing from the latest
+// existing partition. Logs the default partition already holds for a new
+// range are moved into it. It returns the names of those it created.
+func (r *repository) EnsureLogPartitions(ctx context.Context, interval string, ahead int) ([]string, error) {
+	parts, err := r.logPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from := partitionStart(time.Now(), interval)
+	if len(parts) > 0 && parts[len(parts)-1].until.After(from) {
+		from = parts[len(parts)-1].until.UTC()
+	}
+	horizon := time.Now().UTC()
+	for range ahead {
+		horizon = nextPartition(horizon, interval)
+	}
+
+	var created []string
+	for !from.After(horizon) {
+		until := nextPartition(from, interval)
+		name := partitionName(from, interval)
+		if err := r.createLogPartition(ctx, name, from, until); err != nil {
+			return created, fmt.Errorf("create partition %s: %w", name, err)
+		}
+		created = append(created, name)
+		from = until
+	}
+	return created, nil
+}
+
+// createLogPartition creates the partition for [from, until). Postgres
+// refuses while logs_default holds rows in that range (a log dated past the
+// horizon, say), so then the default partition is detached, the partition
+// created, those rows moved into it and the default reattached, all in one
+// transaction. Generated columns, such as those added by IndexMetadataKeys,
+// are left out of the move and recomputed.
+func (r *repository) createLogPartition(ctx context.Context, name string, from, until time.Time) error {
+	create := fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s PARTITION OF logs FOR VALUES FROM ('%s') TO ('%s')`,
+		pgx.Identifier{name}.Sanitize(), from.Format(time.RFC3339), until.Format(time.RFC3339),
+	)
+	_, err := r.pool.Exec(ctx, create)
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23514" {
+		return err
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	cols, err := storedLogColumns(ctx, tx)
+	if err != nil {
+		return err
+	}
+	stmts := []string{
+		`ALTER TABLE logs DETACH PARTITION logs_default`,
+		create,
+		fmt.Sprintf(`
+WITH moved AS (
+    DELETE FROM logs_default WHERE timestamp >= '%s' AND timestamp < '%s' RETURNING %s
+)
+INSERT INTO logs (%[3]s) SELECT %[3]s FROM moved`, from.Format(time.RFC3339), until.Format(time.RFC3339), cols),
+		`ALTER TABLE logs ATTACH PARTITION logs_default DEFAULT`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// DropExpiredLogPartitions drops every partition whose logs all predate
+// before, returning their names, then deletes the logs older than before
+// from the default partition, returning how many. Dropping a partition
+// removes its logs without the cost of deleting them row by row; the default
+// partition only holds strays, so deleting from it stays cheap.
+func (r *repository) DropExpiredLogPartitions(ctx context.Context, before time.Time) ([]string, int64, error) {
+	parts, err := r.logPartitions(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var dropped []string
+	for _, p := range parts {
+		if p.until.After(before) {
+			break
+		}
+		if _, err := r.pool.Exec(ctx, `DROP TABLE `+pgx.Identifier{p.name}.Sanitize()); err != nil {
+			return dropped, 0, fmt.Errorf("drop partition %s: %w", p.name, err)
+		}
+		dropped = append(dropped, p.name)
+	}
+	tag, err := r.pool.Exec(ctx, `DELETE FROM logs_default WHERE timestamp < $1`, before)
+	if err != nil {
+		return dropped, 0, fmt.Errorf("purge logs_default: %w", err)
+	}
+	return dropped, tag.RowsAffected(), nil
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEnsureLogPartitionsMovesDefaultRowsWithIndexedMetadata(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	if err := RunMigrations(ctx, pool, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := PartitionLogs(ctx, pool, PartitionDaily); err != nil {
+		t.Fatalf("partition: %v", err)
+	}
+	if err := IndexMetadataKeys(ctx, pool, []string{"region"}); err != nil {
+		t.Fatalf("index metadata: %v", err)
+	}
+	repo := NewRepository(pool, Options{})
+
+	// Past the legacy partition and with no partition of its own yet, so it
+	// lands in logs_default.
+	ts := time.Now().UTC().AddDate(0, 0, 3)
+	if _, err := repo.InsertLogs(ctx, []LogEntry{{Timestamp: ts, Service: "api", Level: "error", Message: "early", Metadata: []byte(`{"region":"eu-west-1"}`)}}); err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := repo.EnsureLogPartitions(ctx, PartitionDaily, 5)
+	if err != nil {
+		t.Fatalf("ensure partitions: %v", err)
+	}
+	want := partitionName(partitionStart(ts, PartitionDaily), PartitionDaily)
+	found := false
+	for _, name := range created {
+		found = found || name == want
+	}
+	if !found {
+		t.Fatalf("created %v, want %s among them", created, want)
+	}
+
+	var stray int
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM logs_default`).Scan(&stray); err != nil {
+		t.Fatal(err)
+	}
+	if stray != 0 {
+		t.Errorf("%d logs left in logs_default", stray)
+	}
+	var region string
+	if err := pool.QueryRow(ctx, `SELECT `+metadataColumn("region")+` FROM `+want+` WHERE message = 'early'`).Scan(&region); err != nil {
+		t.Fatalf("moved log: %v", err)
+	}
+	if region != "eu-west-1" {
+		t.Errorf("%s = %q after the move, want eu-west-1", metadataColumn("region"), region)
+	}
+}
+
+func TestPartitionLogsFutureLegacyRows(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	if err := RunMigrations(ctx, pool, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := IndexMetadataKeys(ctx, pool, []string{"region"}); err != nil {
+		t.Fatalf("index metadata: %v", err)
+	}
+	repo := NewRepository(pool, Options{})
+	now := time.Now().UTC()
+	if _, err := repo.InsertLogs(ctx, []LogEntry{
+		{Timestamp: now, Service: "api", Level: "error", Message: "current", Metadata: []byte(`{}`)},
+		{Timestamp: now.AddDate(5, 0, 0), Service: "api", Level: "error", Message: "future", Metadata: []byte(`{"region":"eu-west-1"}`)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := PartitionLogs(ctx, pool, PartitionDaily); err != nil {
+		t.Fatalf("partition: %v", err)
+	}
+
+	var legacy, stray int
+	if err := pool.QueryRow(ctx, `
+SELECT
+    (SELECT COUNT(*) FROM logs_legacy),
+    (SELECT COUNT(*) FROM logs_default WHERE message = 'future' AND `+metadataColumn("region")+` = 'eu-west-1')
+`).Scan(&legacy, &stray); err != nil {
+		t.Fatal(err)
+	}
+	if legacy != 1 || stray != 1 {
+		t.Errorf("%d logs in logs_legacy and %d future log in logs_default, want 1 and 1", legacy, stray)
+	}
+
+	created, err := repo.EnsureLogPartitions(ctx, PartitionDaily, 2)
+	if err != nil {
+		t.Fatalf("ensure partitions: %v", err)
+	}
+	if want := partitionName(nextPartition(now, PartitionDaily), PartitionDaily); len(created) == 0 || created[0] != want {
+		t.Errorf("created %v, want partitions starting at %s", created, want)
+	}
+}
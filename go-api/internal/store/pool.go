@@ -0,0 +1,26 @@
+package store
+
+import "time"
+
+// PoolStats is a snapshot of the database connection pool. WaitCount and
+// WaitDuration cover acquires that had to wait for a free connection.
+type PoolStats struct {
+	Acquired     int32
+	Idle         int32
+	Total        int32
+	Max          int32
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+func (r *repository) PoolStats() PoolStats {
+	s := r.pool.Stat()
+	return PoolStats{
+		Acquired:     s.AcquiredConns(),
+		Idle:         s.IdleConns(),
+		Total:        s.TotalConns(),
+		Max:          s.MaxConns(),
+		WaitCount:    s.EmptyAcquireCount(),
+		WaitDuration: s.EmptyAcquireWaitTime(),
+	}
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrCycle is returned when a relation would make an incident its own
+// ancestor.
+var ErrCycle = errors.New("relation would create a cycle")
+
+// IncidentRelation records that ParentID relates to ChildID, e.g. a database
+// incident that caused an API incident.
+type IncidentRelation struct {
+	ParentID     int64     `json:"parent_id"`
+	ChildID      int64     `json:"child_id"`
+	RelationType string    `json:"relation_type"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RelatedIncident is an incident seen from another one. Direction is
+// "parent" when it is the parent of the incident it was listed for and
+// "child" otherwise.
+type RelatedIncident struct {
+	Incident
+	RelationType string `json:"relation_type"`
+	Direction    string `json:"direction"`
+}
+
+// CreateIncidentRelation links two incidents. It returns ErrCycle if the
+// parent is already reachable from the child, ErrConflict if the pair is
+// already linked and ErrNotFound if either incident does not exist.
+func (r *repository) CreateIncidentRelation(ctx context.Context, rel *IncidentRelation) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize relation writes so two concurrent links cannot close a cycle
+	// that neither sees on its own.
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('incident_relations'))`); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(ctx, `
+WITH RECURSIVE descendants AS (
+    SELECT child_id FROM incident_relations WHERE parent_id = $2
+    UNION
+    SELECT r.child_id
+    FROM incident_relations r
+    JOIN descendants d ON r.parent_id = d.child_id
+)
+INSERT INTO incident_relations (parent_id, child_id, relation_type)
+SELECT $1, $2, $3
+WHERE NOT EXISTS (SELECT 1 FROM descendants WHERE child_id = $1)
+RETURNING created_at
+`, rel.ParentID, rel.ChildID, rel.RelationType).Scan(&rel.CreatedAt)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrCycle
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return ErrConflict
+	case errors.As(err, &pgErr) && pgErr.Code == "23503":
+		return ErrNotFound
+	case err != nil:
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *repository) DeleteIncidentRelation(ctx context.Context, parentID, childID int64) error {
+	tag, err := r.pool.Exec(ctx, `
+DELETE FROM incident_relations
+WHERE parent_id = $1 AND child_id = $2
+`, parentID, childID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListRelatedIncidents returns the direct parents and children of an
+// incident.
+func (r *repository) ListRelatedIncidents(ctx context.Context, incidentID int64) ([]RelatedIncident, error) {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT rel.relation_type, rel.direction, `+incidentColumns+`
+FROM incidents
+JOIN (
+    SELECT child_id AS related_id, relation_type, 'child' AS direction
+    FROM incident_relations WHERE parent_id = $1
+    UNION ALL
+    SELECT parent_id, relation_type, 'parent'
+    FROM incident_relations WHERE child_id = $1
+) rel ON rel.related_id = incidents.id
+ORDER BY rel.direction DESC, incidents.created_at
+`, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []RelatedIncident
+	for rows.Next() {
+		var rel RelatedIncident
+		inc, err := scanIncident(prefixedRow{Row: rows, prefix: []any{&rel.RelationType, &rel.Direction}})
+		if err != nil {
+			return nil, err
+		}
+		rel.Incident = inc
+		res = append(res, rel)
+	}
+	return res, rows.Err()
+}
+
+// prefixedRow lets scanIncident read rows that select extra columns ahead of
+// incidentColumns.
+type prefixedRow struct {
+	pgx.Row
+	prefix []any
+}
+
+func (r prefixedRow) Scan(dest ...any) error {
+	return r.Row.Scan(append(r.prefix, dest...)...)
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ListAckReminderDue returns acknowledged, unsnoozed incidents still owed a
+// nudge: a reminder when acknowledged at or before remindBefore, or an
+// escalation when acknowledged at or before escalateBefore (a zero
+// escalateBefore matches none). Longest acknowledged come first, and
+// incidents already sent both drop out, so none is crowded out by newer
+// ones.
+func (r *repository) ListAckReminderDue(ctx context.Context, remindBefore, escalateBefore time.Time, limit int) ([]Incident, error) {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE status = 'acknowledged'
+  AND (snoozed_until IS NULL OR snoozed_until <= NOW())
+  AND ((ack_reminded_at IS NULL AND acknowledged_at <= $1)
+    OR (ack_escalated_at IS NULL AND acknowledged_at <= $2))
+ORDER BY acknowledged_at
+LIMIT $3
+`, remindBefore, escalateBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, inc)
+	}
+	return res, rows.Err()
+}
+
+// MarkAckReminder records that a still-acknowledged incident was sent its
+// "remind" or "escalate" nudge, adding an event of the same kind. It reports
+// false when that nudge was already sent since the last acknowledgement or
+// the incident is no longer acknowledged, so each one goes out once.
+func (r *repository) MarkAckReminder(ctx context.Context, id int64, stage, message string) (bool, error) {
+	var column, kind string
+	switch stage {
+	case "remind":
+		column, kind = "ack_reminded_at", "ack_reminder"
+	case "escalate":
+		column, kind = "ack_escalated_at", "ack_escalated"
+	default:
+		return false, fmt.Errorf("unknown reminder stage %q", stage)
+	}
+
+	var marked int64
+	err := r.pool.QueryRow(ctx, `
+WITH marked AS (
+    UPDATE incidents
+    SET `+column+` = NOW(),
+        updated_at = NOW(),
+        version = version + 1
+    WHERE id = $1 AND status = 'acknowledged' AND `+column+` IS NULL
+    RETURNING id
+)
+INSERT INTO incident_events (incident_id, kind, message, actor)
+SELECT id, $2, $3, 'system'
+FROM marked
+RETURNING incident_id
+`, id, kind, message).Scan(&marked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
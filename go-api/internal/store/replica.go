@@ -0,0 +1,35 @@
+package store
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// reader is the pool for reads that may lag the primary: the read replica
+// when one is configured, otherwise the primary.
+func (r *repository) reader() *pgxpool.Pool {
+	if r.opts.ReadPool != nil {
+		return r.opts.ReadPool
+	}
+	return r.pool
+}
+
+// incidentReader is reader for incident queries, except that for
+// ReplicaLag after this process last wrote an incident it is the primary,
+// so a client reads back what it just created or changed.
+func (r *repository) incidentReader() *pgxpool.Pool {
+	if r.opts.ReadPool == nil {
+		return r.pool
+	}
+	if last := r.lastIncidentWrite.Load(); last != 0 && time.Since(time.Unix(0, last)) < r.opts.ReplicaLag {
+		return r.pool
+	}
+	return r.opts.ReadPool
+}
+
+// noteIncidentWrite starts the window in which incidentReader reads from
+// the primary.
+func (r *repository) noteIncidentWrite() {
+	r.lastIncidentWrite.Store(time.Now().UnixNano())
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ServiceToken is an ingestion credential bound to one service. Only a hash
+// of the token is stored; Prefix is kept so operators can tell tokens apart.
+type ServiceToken struct {
+	ID        int64      `json:"id"`
+	Service   string     `json:"service"`
+	Prefix    string     `json:"prefix"`
+	CreatedAt time.Time  `json:"created_at"`
+	CreatedBy string     `json:"created_by"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+func (r *repository) CreateServiceToken(ctx context.Context, t *ServiceToken, tokenHash string) error {
+	return r.pool.QueryRow(ctx, `
+INSERT INTO service_tokens (service, token_hash, prefix, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING id, created_at
+`, t.Service, tokenHash, t.Prefix, t.CreatedBy).Scan(&t.ID, &t.CreatedAt)
+}
+
+// ListServiceTokens lists tokens newest first, for one service or all of
+// them when service is empty. Revoked tokens are included.
+func (r *repository) ListServiceTokens(ctx context.Context, service string) ([]ServiceToken, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT id, service, prefix, created_at, created_by, revoked_at
+FROM service_tokens
+WHERE $1 = '' OR service = $1
+ORDER BY id DESC
+`, service)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []ServiceToken
+	for rows.Next() {
+		var t ServiceToken
+		if err := rows.Scan(&t.ID, &t.Service, &t.Prefix, &t.CreatedAt, &t.CreatedBy, &t.RevokedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, rows.Err()
+}
+
+// RevokeServiceToken revokes an active token. It returns ErrNotFound when no
+// such token exists or it was already revoked.
+func (r *repository) RevokeServiceToken(ctx context.Context, id int64) error {
+	tag, err := r.pool.Exec(ctx, `
+UPDATE service_tokens SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
+`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// LookupServiceToken finds the active token with the given hash.
+func (r *repository) LookupServiceToken(ctx context.Context, tokenHash string) (*ServiceToken, error) {
+	var t ServiceToken
+	err := r.pool.QueryRow(ctx, `
+SELECT id, service, prefix, created_at, created_by, revoked_at
+FROM service_tokens
+WHERE token_hash = $1 AND revoked_at IS NULL
+`, tokenHash).Scan(&t.ID, &t.Service, &t.Prefix, &t.CreatedAt, &t.CreatedBy, &t.RevokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// trigramSQL enables pg_trgm and indexes incident descriptions for the %
+// similarity operator. pg_trgm is a trusted extension, so a database owner
+// can install it without superuser rights.
+const trigramSQL = `
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_incidents_description_trgm ON incidents USING gin (description gin_trgm_ops);
+`
+
+// SimilarIncident is a resolved incident whose description resembles
+// another's, scored by trigram similarity from 0 to 1.
+type SimilarIncident struct {
+	Incident
+	Similarity float64 `json:"similarity"`
+}
+
+// SimilarIncidents returns resolved incidents, other than id itself, whose
+// descriptions score above minScore against id's, best match first. It
+// returns ErrNotFound if the incident does not exist.
+func (r *repository) SimilarIncidents(ctx context.Context, id int64, limit int, minScore float64) ([]SimilarIncident, error) {
+	tx, err := r.incidentReader().BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var description string
+	err = tx.QueryRow(ctx, `SELECT description FROM incidents WHERE id = $1`, id).Scan(&description)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The % operator is what the trigram index serves; its cutoff is the
+	// session's similarity_threshold, set here for this transaction only.
+	if _, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, strconv.FormatFloat(minScore, 'f', -1, 64)); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, `
+SELECT similarity(description, $2), `+incidentColumns+`
+FROM incidents
+WHERE status = 'resolved' AND id <> $1 AND description % $2
+ORDER BY 1 DESC, resolved_at DESC
+LIMIT $3
+`, id, description, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []SimilarIncident
+	for rows.Next() {
+		var s SimilarIncident
+		inc, err := scanIncident(prefixedRow{Row: rows, prefix: []any{&s.Similarity}})
+		if err != nil {
+			return nil, err
+		}
+		s.Incident = inc
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// FlagSLABreach marks an incident's "ack" or "resolve" SLA as breached and
+// records an sla_breached event. It reports false when the breach had already
+// been flagged, so each breach is announced once.
+func (r *repository) FlagSLABreach(ctx context.Context, id int64, kind, message string) (bool, error) {
+	var column string
+	switch kind {
+	case "ack":
+		column = "ack_sla_breached_at"
+	case "resolve":
+		column = "resolve_sla_breached_at"
+	default:
+		return false, fmt.Errorf("unknown SLA kind %q", kind)
+	}
+
+	var flagged int64
+	err := r.pool.QueryRow(ctx, `
+WITH flagged AS (
+    UPDATE incidents
+    SET `+column+` = NOW(),
+        updated_at = NOW(),
+        version = version + 1
+    WHERE id = $1 AND `+column+` IS NULL
+    RETURNING id
+)
+INSERT INTO incident_events (incident_id, kind, message, actor)
+SELECT id, 'sla_breached', $2, 'system'
+FROM flagged
+RETURNING incident_id
+`, id, message).Scan(&flagged)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SnoozeIncident silences an unresolved incident until the given time,
+// recording a "snoozed" event, and returns its new version. It returns
+// ErrNotFound for a missing incident and ErrConflict for a resolved one.
+func (r *repository) SnoozeIncident(ctx context.Context, id int64, until time.Time, actor, message string) (int64, error) {
+	var version int64
+	err := r.asActor(ctx, actor, func(q auditQuerier) error {
+		return q.QueryRow(ctx, `
+WITH snoozed AS (
+    UPDATE incidents
+    SET snoozed_until = $2,
+        updated_at = NOW(),
+        version = version + 1
+    WHERE id = $1 AND status <> 'resolved'
+    RETURNING id, version
+), event AS (
+    INSERT INTO incident_events (incident_id, kind, message, actor)
+    SELECT id, 'snoozed', $3, $4
+    FROM snoozed
+)
+SELECT version FROM snoozed
+`, id, until, message, actor).Scan(&version)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := r.GetIncident(ctx, id); err != nil {
+			return 0, err
+		}
+		return 0, ErrConflict
+	}
+	return version, err
+}
+
+// UnsnoozeIncident lifts a snooze early, recording an "unsnoozed" event. It
+// returns ErrNotFound when the incident is missing or not snoozed.
+func (r *repository) UnsnoozeIncident(ctx context.Context, id int64, actor string) error {
+	var unsnoozed int64
+	err := r.asActor(ctx, actor, func(q auditQuerier) error {
+		return q.QueryRow(ctx, `
+WITH unsnoozed AS (
+    UPDATE incidents
+    SET snoozed_until = NULL,
+        updated_at = NOW(),
+        version = version + 1
+    WHERE id = $1 AND snoozed_until > NOW()
+    RETURNING id
+)
+INSERT INTO incident_events (incident_id, kind, message, actor)
+SELECT id, 'unsnoozed', 'snooze lifted', $2
+FROM unsnoozed
+RETURNING incident_id
+`, id, actor).Scan(&unsnoozed)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ExpireSnoozes clears every snooze that has run out, recording an
+// "unsnoozed" event on each incident, and returns their IDs.
+func (r *repository) ExpireSnoozes(ctx context.Context) ([]int64, error) {
+	rows, err := r.pool.Query(ctx, `
+WITH expired AS (
+    UPDATE incidents
+    SET snoozed_until = NULL,
+        updated_at = NOW(),
+        version = version + 1
+    WHERE snoozed_until <= NOW()
+    RETURNING id
+)
+INSERT INTO incident_events (incident_id, kind, message, actor)
+SELECT id, 'unsnoozed', 'snooze expired', 'system'
+FROM expired
+RETURNING incident_id
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
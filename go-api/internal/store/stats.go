@@ -0,0 +1,238 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrorLevels are the log levels counted as errors by stats and detection.
+var ErrorLevels = []string{"error", "critical", "fatal", "panic"}
+
+type ServiceVolume struct {
+	Service    string  `json:"service"`
+	Total      int64   `json:"total"`
+	Errors     int64   `json:"errors"`
+	ErrorRatio float64 `json:"error_ratio"`
+}
+
+func (r *repository) TopServices(ctx context.Context, since time.Time, limit int) ([]ServiceVolume, error) {
+	rows, err := r.reader().Query(ctx, `
+SELECT service,
+       COUNT(*) AS total,
+       COUNT(*) FILTER (WHERE level = ANY($2)) AS errors
+FROM logs
+WHERE timestamp >= $1
+GROUP BY service
+ORDER BY total DESC, service
+LIMIT $3
+`, since, ErrorLevels, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []ServiceVolume
+	for rows.Next() {
+		var v ServiceVolume
+		if err := rows.Scan(&v.Service, &v.Total, &v.Errors); err != nil {
+			return nil, err
+		}
+		if v.Total > 0 {
+			v.ErrorRatio = float64(v.Errors) / float64(v.Total)
+		}
+		res = append(res, v)
+	}
+	return res, rows.Err()
+}
+
+// ServiceIncidents rolls up a service's incidents. Service is nil for
+// incidents not attributed to any service.
+type ServiceIncidents struct {
+	Service    *string          `json:"service"`
+	Total      int64            `json:"total"`
+	ByStatus   map[string]int64 `json:"by_status"`
+	BySeverity map[string]int64 `json:"by_severity"`
+}
+
+// IncidentsByService counts incidents created since the given time per
+// service, broken down by status and severity, busiest service first.
+func (r *repository) IncidentsByService(ctx context.Context, since time.Time) ([]ServiceIncidents, error) {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT service, status, severity, COUNT(*)
+FROM incidents
+WHERE created_at >= $1
+GROUP BY service, status, severity
+`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byService := make(map[string]*ServiceIncidents)
+	for rows.Next() {
+		var (
+			service          *string
+			status, severity string
+			n                int64
+		)
+		if err := rows.Scan(&service, &status, &severity, &n); err != nil {
+			return nil, err
+		}
+		key := "\x00"
+		if service != nil {
+			key = *service
+		}
+		s, ok := byService[key]
+		if !ok {
+			s = &ServiceIncidents{Service: service, ByStatus: map[string]int64{}, BySeverity: map[string]int64{}}
+			byService[key] = s
+		}
+		s.Total += n
+		s.ByStatus[status] += n
+		s.BySeverity[severity] += n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var res []ServiceIncidents
+	for _, s := range byService {
+		res = append(res, *s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Total != res[j].Total {
+			return res[i].Total > res[j].Total
+		}
+		return serviceName(res[i].Service) < serviceName(res[j].Service)
+	})
+	return res, nil
+}
+
+func serviceName(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// VolumeBucket is the number of logs in one interval of a log-volume series.
+type VolumeBucket struct {
+	Start   time.Time        `json:"start"`
+	Total   int64            `json:"total"`
+	ByLevel map[string]int64 `json:"by_level"`
+}
+
+// LogVolume counts logs in [since, until) per interval-aligned bucket and
+// level, for one service or all of them when service is empty. Buckets are
+// aligned to origin, which is given in tz. When tz is set and interval is a whole number of days,
+// buckets instead start at local midnight in tz, so they stay on calendar
+// days across DST changes. Buckets with no logs are included with zero
+// counts.
+func (r *repository) LogVolume(ctx context.Context, service string, interval time.Duration, since, until, origin time.Time, tz string) ([]VolumeBucket, error) {
+	query := `
+WITH counts AS (
+    SELECT date_bin($1::interval, timestamp, $5::timestamptz) AS bucket, level, COUNT(*) AS n
+    FROM logs
+    WHERE timestamp >= $2 AND timestamp < $3 AND ($4 = '' OR service = $4)
+    GROUP BY 1, 2
+)
+SELECT b.bucket, c.level, COALESCE(c.n, 0)
+FROM generate_series(
+    date_bin($1::interval, $2::timestamptz, $5::timestamptz),
+    $3::timestamptz - INTERVAL '1 microsecond',
+    $1::interval
+) AS b(bucket)
+LEFT JOIN counts c ON c.bucket = b.bucket
+ORDER BY b.bucket
+`
+	args := []any{interval, since, until, service, origin}
+	if tz != "" && interval%(24*time.Hour) == 0 {
+		// Bin local wall-clock times, which have no DST jumps: for one day
+		// this is date_trunc('day', timestamp AT TIME ZONE tz).
+		days := int(interval / (24 * time.Hour))
+		query = `
+WITH counts AS (
+    SELECT date_bin($1::interval, timestamp AT TIME ZONE $6, $5::timestamp) AS bucket, level, COUNT(*) AS n
+    FROM logs
+    WHERE timestamp >= $2 AND timestamp < $3 AND ($4 = '' OR service = $4)
+    GROUP BY 1, 2
+)
+SELECT b.bucket AT TIME ZONE $6, c.level, COALESCE(c.n, 0)
+FROM generate_series(
+    date_bin($1::interval, $2::timestamptz AT TIME ZONE $6, $5::timestamp),
+    ($3::timestamptz - INTERVAL '1 microsecond') AT TIME ZONE $6,
+    $1::interval
+) AS b(bucket)
+LEFT JOIN counts c ON c.bucket = b.bucket
+ORDER BY b.bucket
+`
+		args = []any{fmt.Sprintf("%d days", days), since, until, service, origin.Format("2006-01-02"), tz}
+	}
+	rows, err := r.reader().Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []VolumeBucket
+	for rows.Next() {
+		var (
+			start time.Time
+			level *string
+			n     int64
+		)
+		if err := rows.Scan(&start, &level, &n); err != nil {
+			return nil, err
+		}
+		if len(res) == 0 || !res[len(res)-1].Start.Equal(start) {
+			res = append(res, VolumeBucket{Start: start.UTC(), ByLevel: map[string]int64{}})
+		}
+		if level != nil {
+			b := &res[len(res)-1]
+			b.Total += n
+			b.ByLevel[*level] += n
+		}
+	}
+	return res, rows.Err()
+}
+
+// ErrorSeriesByService counts error logs per service in interval-wide
+// buckets starting at since, up to until. Each series has one zero-filled
+// entry per bucket; services with no errors in the range are left out.
+func (r *repository) ErrorSeriesByService(ctx context.Context, interval time.Duration, since, until time.Time) (map[string][]int64, error) {
+	buckets := int((until.Sub(since) + interval - 1) / interval)
+	rows, err := r.reader().Query(ctx, `
+SELECT service, floor(extract(epoch FROM timestamp - $1::timestamptz) / $3::float8)::int AS bucket, COUNT(*)
+FROM logs
+WHERE timestamp >= $1 AND timestamp < $2 AND level = ANY($4)
+GROUP BY 1, 2
+`, since, until, interval.Seconds(), ErrorLevels)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string][]int64)
+	for rows.Next() {
+		var (
+			service string
+			bucket  int
+			n       int64
+		)
+		if err := rows.Scan(&service, &bucket, &n); err != nil {
+			return nil, err
+		}
+		if bucket < 0 || bucket >= buckets {
+			continue
+		}
+		series, ok := res[service]
+		if !ok {
+			series = make([]int64, buckets)
+			res[service] = series
+		}
+		series[bucket] = n
+	}
+	return res, rows.Err()
+}
@@ -2,19 +2,37 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"Incident_Monitoring_Project/internal/auth"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+	// ErrStaleVersion means a conditional update expected an incident
+	// version that has since moved on.
+	ErrStaleVersion = errors.New("stale version")
 )
 
 type LogEntry struct {
-	ID        int64     `json:"id"`
-	Timestamp time.Time `json:"timestamp"`
-	Service   string    `json:"service"`
-	Level     string    `json:"level"`
-	Message   string    `json:"message"`
-	Metadata  string    `json:"metadata"`
+	ID        int64           `json:"id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Service   string          `json:"service"`
+	Level     string          `json:"level"`
+	Message   string          `json:"message"`
+	Metadata  json.RawMessage `json:"metadata"`
 }
 
 type Incident struct {
@@ -26,29 +44,225 @@ type Incident struct {
 	Summary     *string    `json:"summary"`
 	RootCause   *string    `json:"root_cause"`
 	ResolvedAt  *time.Time `json:"resolved_at"`
+	Service     *string    `json:"service"`
+	AutoCreated bool       `json:"auto_created"`
+	Tags        []string   `json:"tags"`
+
+	// ExternalID is an optional caller-supplied key, unique across
+	// incidents, that makes creation idempotent.
+	ExternalID *string `json:"external_id"`
+
+	OccurrenceCount int        `json:"occurrence_count"`
+	LastSeenAt      *time.Time `json:"last_seen_at"`
+	AcknowledgedBy  *string    `json:"acknowledged_by"`
+	AcknowledgedAt  *time.Time `json:"acknowledged_at"`
+
+	SummaryUpdatedAt *time.Time `json:"summary_updated_at"`
+
+	// SnoozedUntil silences the incident in the on-call queue and ack
+	// reminders until it passes.
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Version is bumped on every write to the incident row; clients send it
+	// back in If-Match to detect concurrent edits.
+	Version int64 `json:"version"`
+
+	// SLA is computed per response from the configured targets, not stored.
+	SLA *SLAStatus `json:"sla,omitempty"`
+
+	// Display is computed per response from the configured severity
+	// presentation, not stored.
+	Display *SeverityDisplay `json:"display,omitempty"`
+}
+
+// SeverityDisplay is how clients should present an incident's severity.
+type SeverityDisplay struct {
+	Label  string `json:"label"`
+	Color  string `json:"color"`
+	Weight int    `json:"weight"`
+}
+
+// SLAStatus is an incident's position against its acknowledge and resolve
+// targets.
+type SLAStatus struct {
+	AckDueAt        *time.Time `json:"ack_due_at,omitempty"`
+	AckBreached     bool       `json:"ack_breached"`
+	ResolveDueAt    *time.Time `json:"resolve_due_at,omitempty"`
+	ResolveBreached bool       `json:"resolve_breached"`
+}
+
+type IncidentEvent struct {
+	ID         int64     `json:"id"`
+	IncidentID int64     `json:"incident_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Kind       string    `json:"kind"`
+	Message    string    `json:"message"`
+	Actor      string    `json:"actor"`
 }
 
 type Repository interface {
-	InsertLogs(ctx context.Context, logs []LogEntry) error
+	InsertLogs(ctx context.Context, logs []LogEntry) ([]int64, error)
 	ListRecentLogs(ctx context.Context, limit int) ([]LogEntry, error)
+	ListRecentServiceLogs(ctx context.Context, service string, limit int) ([]LogEntry, error)
+	GetLog(ctx context.Context, id int64) (*LogEntry, error)
+	GetLogsByIDs(ctx context.Context, ids []int64) ([]LogEntry, error)
+	LogContext(ctx context.Context, id int64, before, after int) (*LogContext, error)
+	ListLogs(ctx context.Context, q LogQuery) ([]LogEntry, error)
+
+	SaveFailedIngestion(ctx context.Context, logs []LogEntry, cause error) error
+	ListFailedIngestions(ctx context.Context, limit int) ([]FailedIngestion, error)
+	DeleteFailedIngestion(ctx context.Context, id int64) error
+	MarkFailedIngestionRetry(ctx context.Context, id int64, cause error) error
 
-	CreateIncident(ctx context.Context, inc *Incident) error
-	ListIncidents(ctx context.Context, limit int) ([]Incident, error)
+	CreateIncident(ctx context.Context, inc *Incident) (bool, error)
+	GetIncidentByExternalID(ctx context.Context, externalID string) (*Incident, error)
+	ListIncidents(ctx context.Context, limit int, sort IncidentSort) ([]Incident, error)
+	ListServiceIncidents(ctx context.Context, service string, limit int, sort IncidentSort) ([]Incident, error)
+	ListRecentlyUpdatedIncidents(ctx context.Context, severities []string, limit int) ([]Incident, error)
+	ListIncidentsSince(ctx context.Context, since time.Time) ([]Incident, error)
+	CountIncidents(ctx context.Context, service string) (int64, error)
+	StreamIncidents(ctx context.Context, f IncidentFilter, fn func(Incident) error) error
 	GetIncident(ctx context.Context, id int64) (*Incident, error)
+	GetIncidentsByIDs(ctx context.Context, ids []int64) ([]Incident, error)
 	UpdateIncidentSummary(ctx context.Context, id int64, summary, rootCause string) error
-	UpdateIncidentStatus(ctx context.Context, id int64, status string) error
+	ImportIncidentSummaries(ctx context.Context, imports []SummaryImport) ([]bool, error)
+	UpdateIncidentStatus(ctx context.Context, id int64, status string, version int64) (int64, error)
+	AcknowledgeIncident(ctx context.Context, id int64, by string, version int64) (int64, error)
+	ListUnresolvedIncidents(ctx context.Context, limit int) ([]Incident, error)
+	ListUnanalyzedIncidents(ctx context.Context, limit int) ([]Incident, error)
+	AutoResolveQuietIncidents(ctx context.Context, quietSince time.Time) ([]int64, error)
+	FlagSLABreach(ctx context.Context, id int64, kind, message string) (bool, error)
+	ListAckReminderDue(ctx context.Context, remindBefore, escalateBefore time.Time, limit int) ([]Incident, error)
+	MarkAckReminder(ctx context.Context, id int64, stage, message string) (bool, error)
+	SnoozeIncident(ctx context.Context, id int64, until time.Time, actor, message string) (int64, error)
+	UnsnoozeIncident(ctx context.Context, id int64, actor string) error
+	RetagIncidents(ctx context.Context, ids []int64, actor string, retag func(tags []string) ([]string, error)) ([]RetagResult, error)
+	ExpireSnoozes(ctx context.Context) ([]int64, error)
+
+	ListIncidentEvents(ctx context.Context, incidentID int64) ([]IncidentEvent, error)
+	ListIncidentAudit(ctx context.Context, incidentID int64) ([]IncidentAuditEntry, error)
+	AddIncidentEvent(ctx context.Context, incidentID int64, kind, message, actor string) error
+
+	CreateIncidentLink(ctx context.Context, link *IncidentLink) error
+	ListIncidentLinks(ctx context.Context, incidentID int64) ([]IncidentLink, error)
+
+	CreateIncidentRelation(ctx context.Context, rel *IncidentRelation) error
+	DeleteIncidentRelation(ctx context.Context, parentID, childID int64) error
+	ListRelatedIncidents(ctx context.Context, incidentID int64) ([]RelatedIncident, error)
+	SimilarIncidents(ctx context.Context, id int64, limit int, minScore float64) ([]SimilarIncident, error)
+
+	AddIncidentWatcher(ctx context.Context, incidentID int64, subscriber string) error
+	RemoveIncidentWatcher(ctx context.Context, incidentID int64, subscriber string) error
+	ListIncidentWatchers(ctx context.Context, incidentID int64) ([]string, error)
+
+	CreateIncidentComment(ctx context.Context, comment *IncidentComment) error
+	ListIncidentComments(ctx context.Context, incidentID int64) ([]IncidentComment, error)
+
+	CreateIncidentTemplate(ctx context.Context, t *IncidentTemplate) error
+	ListIncidentTemplates(ctx context.Context) ([]IncidentTemplate, error)
+	GetIncidentTemplate(ctx context.Context, name string) (*IncidentTemplate, error)
+	DeleteIncidentTemplate(ctx context.Context, name string) error
+
+	CreateServiceToken(ctx context.Context, t *ServiceToken, tokenHash string) error
+	ListServiceTokens(ctx context.Context, service string) ([]ServiceToken, error)
+	RevokeServiceToken(ctx context.Context, id int64) error
+	LookupServiceToken(ctx context.Context, tokenHash string) (*ServiceToken, error)
+
+	CreateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error
+	UpdateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error
+	ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error)
+	ListDeadWebhookDeliveries(ctx context.Context, limit int) ([]WebhookDelivery, error)
+
+	EnsureLogPartitions(ctx context.Context, interval string, ahead int) ([]string, error)
+	DropExpiredLogPartitions(ctx context.Context, before time.Time) ([]string, int64, error)
+
+	CreateJob(ctx context.Context, j *Job) error
+	SaveJob(ctx context.Context, j *Job) error
+	GetJob(ctx context.Context, id string) (*Job, error)
+	InterruptJobs(ctx context.Context, staleBefore time.Time) (int64, error)
+	DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error)
+
+	PoolStats() PoolStats
+
+	TopServices(ctx context.Context, since time.Time, limit int) ([]ServiceVolume, error)
+	IncidentsByService(ctx context.Context, since time.Time) ([]ServiceIncidents, error)
+	LogVolume(ctx context.Context, service string, interval time.Duration, since, until, origin time.Time, tz string) ([]VolumeBucket, error)
+	ErrorSeriesByService(ctx context.Context, interval time.Duration, since, until time.Time) (map[string][]int64, error)
+	DistinctLogServices(ctx context.Context, since *time.Time) ([]string, error)
+	DistinctLogLevels(ctx context.Context, since *time.Time) ([]string, error)
+	ServiceMetadataKeys(ctx context.Context, service string, limit int) ([]MetadataKey, int64, error)
+	VacuumAnalyze(ctx context.Context, tables []string) error
+
+	ErrorBursts(ctx context.Context, since, until time.Time, threshold int) ([]ErrorBurst, error)
+	ServiceErrorBurst(ctx context.Context, service string, since, until time.Time, threshold int) (*ErrorBurst, error)
+	ErrorLogGroups(ctx context.Context, service string, since, until time.Time, limit int) ([]LogGroup, error)
+	ListErrorLogs(ctx context.Context, since, until time.Time, limit int) ([]LogEntry, error)
+	UpsertDetectionRule(ctx context.Context, rule *DetectionRule) error
+	ListDetectionRules(ctx context.Context) ([]DetectionRule, error)
+	DeleteDetectionRule(ctx context.Context, service string) error
+	FindOpenAutoIncident(ctx context.Context, service string) (*Incident, error)
+	RecordIncidentOccurrence(ctx context.Context, id int64, lastSeen time.Time) error
+
+	CreateMaintenanceWindow(ctx context.Context, w *MaintenanceWindow) error
+	ListMaintenanceWindows(ctx context.Context, activeAt *time.Time) ([]MaintenanceWindow, error)
+	InMaintenance(ctx context.Context, service string, at time.Time) (bool, error)
+	TagMaintenanceIncidents(ctx context.Context) ([]int64, error)
+}
+
+// Options tunes how the repository stores data.
+type Options struct {
+	// CompressMessagesOver gzips log messages of at least this many bytes
+	// into message_gz, keeping a short preview in message. Zero disables it.
+	CompressMessagesOver int
+	// ReadPool, when set, serves log, stats and incident reads that can
+	// tolerate replication lag; writes always go to the primary.
+	ReadPool *pgxpool.Pool
+	// ReplicaLag is how long after writing an incident its reads stay on
+	// the primary.
+	ReplicaLag time.Duration
 }
 
 type repository struct {
 	pool *pgxpool.Pool
+	opts Options
+
+	// lastIncidentWrite is the Unix nanosecond time of the last incident
+	// write made through this repository.
+	lastIncidentWrite atomic.Int64
 }
 
-func NewRepository(pool *pgxpool.Pool) Repository {
-	return &repository{pool: pool}
+func NewRepository(pool *pgxpool.Pool, opts Options) Repository {
+	return &repository{pool: pool, opts: opts}
 }
 
-func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
-	_, err := pool.Exec(ctx, `
+// RunMigrations brings the schema up to date. It is safe to run on every
+// start and on databases created by older versions.
+func RunMigrations(ctx context.Context, pool *pgxpool.Pool, backfillBatch int) error {
+	if backfillBatch <= 0 {
+		backfillBatch = DefaultBackfillBatch
+	}
+	if _, err := pool.Exec(ctx, schemaSQL); err != nil {
+		return err
+	}
+	for _, m := range columnMigrations {
+		if err := migrateColumn(ctx, pool, m, backfillBatch); err != nil {
+			return err
+		}
+	}
+	if _, err := pool.Exec(ctx, postColumnSQL); err != nil {
+		return err
+	}
+	if _, err := pool.Exec(ctx, trigramSQL); err != nil {
+		return fmt.Errorf("pg_trgm: %w", err)
+	}
+	return nil
+}
+
+// schemaSQL creates the tables and adds the columns that are nullable or
+// cheap to add; columns that need a backfill are in columnMigrations.
+const schemaSQL = `
 CREATE TABLE IF NOT EXISTS logs (
     id SERIAL PRIMARY KEY,
     timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
@@ -69,39 +283,292 @@ CREATE TABLE IF NOT EXISTS incidents (
     resolved_at TIMESTAMPTZ
 );
 
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS service TEXT;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS acknowledged_by TEXT;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS acknowledged_at TIMESTAMPTZ;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS summary_updated_at TIMESTAMPTZ;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS ack_sla_breached_at TIMESTAMPTZ;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS resolve_sla_breached_at TIMESTAMPTZ;
+ALTER TABLE logs ADD COLUMN IF NOT EXISTS message_gz BYTEA;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS ack_reminded_at TIMESTAMPTZ;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS ack_escalated_at TIMESTAMPTZ;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS external_id TEXT;
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMPTZ;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_incidents_external_id ON incidents(external_id);
+
+CREATE TABLE IF NOT EXISTS incident_events (
+    id SERIAL PRIMARY KEY,
+    incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    kind TEXT NOT NULL,
+    message TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS incident_links (
+    id SERIAL PRIMARY KEY,
+    incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    label TEXT NOT NULL,
+    url TEXT NOT NULL,
+    kind TEXT NOT NULL DEFAULT 'other'
+);
+
+CREATE TABLE IF NOT EXISTS incident_watchers (
+    incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    subscriber TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (incident_id, subscriber)
+);
+
+CREATE TABLE IF NOT EXISTS incident_comments (
+    id SERIAL PRIMARY KEY,
+    incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    author TEXT,
+    body TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS incident_templates (
+    name TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    default_severity TEXT NOT NULL DEFAULT 'medium',
+    description_template TEXT NOT NULL,
+    tags TEXT[] NOT NULL DEFAULT '{}'
+);
+
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    service TEXT,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS incident_relations (
+    parent_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    child_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    relation_type TEXT NOT NULL DEFAULT 'related',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (parent_id, child_id),
+    CHECK (parent_id <> child_id)
+);
+
+CREATE TABLE IF NOT EXISTS detection_rules (
+    service TEXT PRIMARY KEY,
+    window_seconds INTEGER NOT NULL DEFAULT 0,
+    threshold INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS failed_ingestions (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    error TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    payload JSONB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS service_tokens (
+    id SERIAL PRIMARY KEY,
+    service TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    prefix TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by TEXT NOT NULL DEFAULT '',
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    notifier TEXT NOT NULL,
+    incident_id BIGINT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ
+);
+
 CREATE INDEX IF NOT EXISTS idx_logs_timestamp ON logs(timestamp);
 CREATE INDEX IF NOT EXISTS idx_logs_service ON logs(service);
 CREATE INDEX IF NOT EXISTS idx_incidents_status ON incidents(status);
-`)
-	return err
+CREATE INDEX IF NOT EXISTS idx_incidents_snoozed_until ON incidents(snoozed_until) WHERE snoozed_until IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_incidents_service ON incidents(service);
+CREATE INDEX IF NOT EXISTS idx_incidents_acknowledged_at ON incidents(acknowledged_at) WHERE status = 'acknowledged';
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_ends_at ON maintenance_windows(ends_at);
+CREATE INDEX IF NOT EXISTS idx_incident_events_incident ON incident_events(incident_id);
+CREATE INDEX IF NOT EXISTS idx_incident_links_incident ON incident_links(incident_id);
+CREATE INDEX IF NOT EXISTS idx_incident_relations_child ON incident_relations(child_id);
+CREATE INDEX IF NOT EXISTS idx_incident_comments_incident ON incident_comments(incident_id);
+CREATE INDEX IF NOT EXISTS idx_service_tokens_service ON service_tokens(service);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_dead ON webhook_deliveries(id) WHERE status = 'dead';
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL,
+    created_by TEXT NOT NULL DEFAULT '',
+    total INTEGER NOT NULL DEFAULT 0,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    errors TEXT[] NOT NULL DEFAULT '{}',
+    error TEXT NOT NULL DEFAULT '',
+    result JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+-- Constant defaults, so adding these to an existing table is catalog-only.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS exclusive BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_jobs_unfinished ON jobs(status) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_jobs_finished_at ON jobs(finished_at);
+-- One unfinished exclusive job per kind, across every instance.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_exclusive_kind ON jobs(kind) WHERE exclusive AND status IN ('queued', 'running');
+
+-- Every insert and update of an incident row is snapshotted, before and
+-- after, attributed to the actor set in the writing transaction.
+-- updated_at and version change on every write, so they alone never make
+-- an entry; nor do occurrence_count and last_seen_at, which detection bumps
+-- on every tick while an incident keeps firing.
+CREATE TABLE IF NOT EXISTS incident_audit (
+    id BIGSERIAL PRIMARY KEY,
+    incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor TEXT NOT NULL,
+    before JSONB,
+    after JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_incident_audit_incident ON incident_audit(incident_id, id);
+`
+
+// postColumnSQL depends on columns from columnMigrations.
+const postColumnSQL = `
+CREATE INDEX IF NOT EXISTS idx_incidents_updated_at ON incidents (updated_at DESC);
+
+CREATE OR REPLACE FUNCTION record_incident_audit() RETURNS trigger AS $$
+DECLARE
+    old_row JSONB;
+    new_row JSONB := to_jsonb(NEW);
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_row := to_jsonb(OLD);
+        IF old_row - '{updated_at,version,occurrence_count,last_seen_at}'::text[]
+            = new_row - '{updated_at,version,occurrence_count,last_seen_at}'::text[] THEN
+            RETURN NULL;
+        END IF;
+    END IF;
+    INSERT INTO incident_audit (incident_id, actor, before, after)
+    VALUES (NEW.id, COALESCE(NULLIF(current_setting('` + auditActorSetting + `', true), ''), 'system'), old_row, new_row);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS incidents_audit ON incidents;
+CREATE TRIGGER incidents_audit
+AFTER INSERT OR UPDATE ON incidents
+FOR EACH ROW EXECUTE FUNCTION record_incident_audit();
+`
+
+const insertLogSQL = `INSERT INTO logs (timestamp, service, level, message, metadata, message_compressed, message_gz)
+VALUES ($1, $2, $3, $4, COALESCE($5::jsonb, '{}'::jsonb), $6, $7)
+RETURNING id`
+
+func (r *repository) insertLogArgs(l LogEntry) []any {
+	message := l.Message
+	preview, gz, compressed := compressMessage(message, r.opts.CompressMessagesOver)
+	if compressed {
+		message = preview
+	}
+	return []any{l.Timestamp, l.Service, l.Level, message, l.Metadata, compressed, gz}
+}
+
+// InsertLogs stores logs in a single batch and returns their generated IDs in
+// input order. If the database rejects any of them, the rest are still
+// stored and a *PartialInsertError lists the rejected rows, whose IDs are
+// zero.
+func (r *repository) InsertLogs(ctx context.Context, logs []LogEntry) ([]int64, error) {
+	ids, err := r.insertLogBatch(ctx, logs)
+	var pgErr *pgconn.PgError
+	if err == nil || !errors.As(err, &pgErr) {
+		return ids, err
+	}
+	// The batch runs as one implicit transaction, so nothing was stored.
+	// Go again row by row to find the offending logs and keep the others.
+	return r.insertLogsByRow(ctx, logs)
 }
 
-func (r *repository) InsertLogs(ctx context.Context, logs []LogEntry) error {
+func (r *repository) insertLogBatch(ctx context.Context, logs []LogEntry) ([]int64, error) {
 	batch := &pgx.Batch{}
 	for _, l := range logs {
-		batch.Queue(
-			`INSERT INTO logs (timestamp, service, level, message, metadata)
-             VALUES ($1, $2, $3, $4, COALESCE($5::jsonb, '{}'::jsonb))`,
-			l.Timestamp, l.Service, l.Level, l.Message, l.Metadata,
-		)
+		batch.Queue(insertLogSQL, r.insertLogArgs(l)...)
 	}
 	br := r.pool.SendBatch(ctx, batch)
 	defer br.Close()
 
+	ids := make([]int64, len(logs))
 	for i := 0; i < len(logs); i++ {
-		_, err := br.Exec()
+		if err := br.QueryRow().Scan(&ids[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// insertLogsByRow inserts each log under its own savepoint in one
+// transaction, so a rejected row is rolled back alone.
+func (r *repository) insertLogsByRow(ctx context.Context, logs []LogEntry) ([]int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	ids := make([]int64, len(logs))
+	partial := &PartialInsertError{}
+	for i, l := range logs {
+		sp, err := tx.Begin(ctx)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		err = sp.QueryRow(ctx, insertLogSQL, r.insertLogArgs(l)...).Scan(&ids[i])
+		if err == nil {
+			err = sp.Commit(ctx)
+		}
+		var pgErr *pgconn.PgError
+		if err != nil && !errors.As(err, &pgErr) {
+			return nil, err
 		}
+		if err != nil {
+			if rbErr := sp.Rollback(ctx); rbErr != nil {
+				return nil, rbErr
+			}
+			ids[i] = 0
+			partial.Rows = append(partial.Rows, LogRowError{Index: i, Err: err})
+			continue
+		}
+		partial.Inserted++
 	}
-	return nil
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	if len(partial.Rows) > 0 {
+		return ids, partial
+	}
+	return ids, nil
 }
 
 func (r *repository) ListRecentLogs(ctx context.Context, limit int) ([]LogEntry, error) {
 	rows, err := r.pool.Query(ctx, `
-SELECT id, timestamp, service, level, message, metadata
+SELECT `+logColumns+`
 FROM logs
-ORDER BY timestamp DESC
+ORDER BY timestamp DESC, id DESC
 LIMIT $1
 `, limit)
 	if err != nil {
@@ -111,8 +578,32 @@ LIMIT $1
 
 	var res []LogEntry
 	for rows.Next() {
-		var l LogEntry
-		if err := rows.Scan(&l.ID, &l.Timestamp, &l.Service, &l.Level, &l.Message, &l.Metadata); err != nil {
+		l, err := scanLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, l)
+	}
+	return res, rows.Err()
+}
+
+func (r *repository) ListRecentServiceLogs(ctx context.Context, service string, limit int) ([]LogEntry, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT `+logColumns+`
+FROM logs
+WHERE service = $1
+ORDER BY timestamp DESC, id DESC
+LIMIT $2
+`, service, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []LogEntry
+	for rows.Next() {
+		l, err := scanLog(rows)
+		if err != nil {
 			return nil, err
 		}
 		res = append(res, l)
@@ -120,21 +611,190 @@ LIMIT $1
 	return res, rows.Err()
 }
 
-func (r *repository) CreateIncident(ctx context.Context, inc *Incident) error {
-	return r.pool.QueryRow(ctx, `
-INSERT INTO incidents (status, severity, description)
-VALUES ($1, $2, $3)
-RETURNING id, created_at
-`, inc.Status, inc.Severity, inc.Description).Scan(&inc.ID, &inc.CreatedAt)
+// LogQuery pages through logs in (timestamp, id) order, which stays stable
+// when many logs share a timestamp. Cursor is the ID of the last log of the
+// previous page; zero starts from the beginning. Since and Until, when set,
+// bound the timestamps to [Since, Until). Metadata filters on exact values of
+// keys promoted by IndexMetadataKeys.
+type LogQuery struct {
+	Service   string
+	Since     *time.Time
+	Until     *time.Time
+	Metadata  map[string]string
+	Ascending bool
+	Cursor    int64
+	Limit     int
 }
 
-func (r *repository) ListIncidents(ctx context.Context, limit int) ([]Incident, error) {
-	rows, err := r.pool.Query(ctx, `
-SELECT id, created_at, status, severity, description, summary, root_cause, resolved_at
+func (r *repository) ListLogs(ctx context.Context, q LogQuery) ([]LogEntry, error) {
+	cmp, dir := "<", "DESC"
+	if q.Ascending {
+		cmp, dir = ">", "ASC"
+	}
+	args := []any{q.Service, q.Cursor, q.Limit, q.Since, q.Until}
+	var metaFilter strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(q.Metadata)) {
+		if !ValidMetadataKey(key) {
+			return nil, fmt.Errorf("invalid metadata key %q", key)
+		}
+		args = append(args, q.Metadata[key])
+		fmt.Fprintf(&metaFilter, "\n  AND %s = $%d", metadataColumn(key), len(args))
+	}
+
+	rows, err := r.reader().Query(ctx, `
+SELECT `+logColumns+`
+FROM logs
+WHERE ($1 = '' OR service = $1)
+  AND ($2::bigint = 0 OR (timestamp, id) `+cmp+` (SELECT timestamp, id FROM logs WHERE id = $2))
+  AND ($4::timestamptz IS NULL OR timestamp >= $4)
+  AND ($5::timestamptz IS NULL OR timestamp < $5)`+metaFilter.String()+`
+ORDER BY timestamp `+dir+`, id `+dir+`
+LIMIT $3
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []LogEntry
+	for rows.Next() {
+		l, err := scanLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, l)
+	}
+	return res, rows.Err()
+}
+
+func (r *repository) GetLog(ctx context.Context, id int64) (*LogEntry, error) {
+	l, err := scanLog(r.reader().QueryRow(ctx, `
+SELECT `+logColumns+`
+FROM logs
+WHERE id = $1
+`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// LogContext is a log together with its neighbours from the same service,
+// each side oldest first.
+type LogContext struct {
+	Log    LogEntry   `json:"log"`
+	Before []LogEntry `json:"before"`
+	After  []LogEntry `json:"after"`
+}
+
+// LogContext returns the log with the given ID and up to before and after
+// logs from the same service immediately around it, ordered by timestamp
+// then id.
+func (r *repository) LogContext(ctx context.Context, id int64, before, after int) (*LogContext, error) {
+	target, err := r.GetLog(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.reader().Query(ctx, `
+(SELECT `+logColumns+`
+ FROM logs
+ WHERE service = $1 AND (timestamp, id) < ($2, $3)
+ ORDER BY timestamp DESC, id DESC
+ LIMIT $4)
+UNION ALL
+(SELECT `+logColumns+`
+ FROM logs
+ WHERE service = $1 AND (timestamp, id) > ($2, $3)
+ ORDER BY timestamp, id
+ LIMIT $5)
+`, target.Service, target.Timestamp, target.ID, before, after)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := &LogContext{Log: *target, Before: []LogEntry{}, After: []LogEntry{}}
+	for rows.Next() {
+		l, err := scanLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		if l.Timestamp.Before(target.Timestamp) || (l.Timestamp.Equal(target.Timestamp) && l.ID < target.ID) {
+			res.Before = append(res.Before, l)
+		} else {
+			res.After = append(res.After, l)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.Reverse(res.Before)
+	return res, nil
+}
+
+// CreateIncident inserts inc and fills in its generated fields. When
+// inc.ExternalID is already taken it inserts nothing, replaces inc with the
+// existing incident and reports false.
+func (r *repository) CreateIncident(ctx context.Context, inc *Incident) (bool, error) {
+	var created bool
+	err := r.asActor(ctx, auth.Actor(ctx), func(q auditQuerier) error {
+		var err error
+		created, err = createIncident(ctx, q, inc)
+		return err
+	})
+	return created, err
+}
+
+func createIncident(ctx context.Context, q auditQuerier, inc *Incident) (bool, error) {
+	if inc.ExternalID == nil {
+		return true, q.QueryRow(ctx, `
+INSERT INTO incidents (status, severity, description, service, auto_created, tags, last_seen_at)
+VALUES ($1, $2, $3, $4, $5, COALESCE($6::text[], '{}'), $7)
+RETURNING id, created_at, updated_at, occurrence_count, version
+`, inc.Status, inc.Severity, inc.Description, inc.Service, inc.AutoCreated, inc.Tags, inc.LastSeenAt).Scan(&inc.ID, &inc.CreatedAt, &inc.UpdatedAt, &inc.OccurrenceCount, &inc.Version)
+	}
+
+	// A concurrent insert of the same key can commit after this statement's
+	// snapshot was taken, so that neither branch returns a row; the second
+	// attempt sees it.
+	for attempt := 0; ; attempt++ {
+		var created bool
+		existing, err := scanIncident(prefixedRow{Row: q.QueryRow(ctx, `
+WITH ins AS (
+    INSERT INTO incidents (status, severity, description, service, auto_created, tags, last_seen_at, external_id)
+    VALUES ($1, $2, $3, $4, $5, COALESCE($6::text[], '{}'), $7, $8)
+    ON CONFLICT (external_id) DO NOTHING
+    RETURNING `+incidentColumns+`
+)
+SELECT TRUE, * FROM ins
+UNION ALL
+SELECT FALSE, `+incidentColumns+` FROM incidents
+WHERE external_id = $8 AND NOT EXISTS (SELECT 1 FROM ins)
+`, inc.Status, inc.Severity, inc.Description, inc.Service, inc.AutoCreated, inc.Tags, inc.LastSeenAt, inc.ExternalID), prefix: []any{&created}})
+		if errors.Is(err, pgx.ErrNoRows) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		*inc = existing
+		return created, nil
+	}
+}
+
+// GetIncidentsByIDs returns the incidents with the given IDs in the order
+// the IDs are listed. IDs with no incident are skipped.
+func (r *repository) GetIncidentsByIDs(ctx context.Context, ids []int64) ([]Incident, error) {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT `+incidentColumns+`
 FROM incidents
-ORDER BY created_at DESC
-LIMIT $1
-`, limit)
+WHERE id = ANY($1::bigint[])
+ORDER BY array_position($1::bigint[], id::bigint)
+`, ids)
 	if err != nil {
 		return nil, err
 	}
@@ -142,17 +802,8 @@ LIMIT $1
 
 	var res []Incident
 	for rows.Next() {
-		var inc Incident
-		if err := rows.Scan(
-			&inc.ID,
-			&inc.CreatedAt,
-			&inc.Status,
-			&inc.Severity,
-			&inc.Description,
-			&inc.Summary,
-			&inc.RootCause,
-			&inc.ResolvedAt,
-		); err != nil {
+		inc, err := scanIncident(rows)
+		if err != nil {
 			return nil, err
 		}
 		res = append(res, inc)
@@ -160,15 +811,26 @@ LIMIT $1
 	return res, rows.Err()
 }
 
-func (r *repository) GetIncident(ctx context.Context, id int64) (*Incident, error) {
-	row := r.pool.QueryRow(ctx, `
-SELECT id, created_at, status, severity, description, summary, root_cause, resolved_at
+func (r *repository) GetIncidentByExternalID(ctx context.Context, externalID string) (*Incident, error) {
+	inc, err := scanIncident(r.incidentReader().QueryRow(ctx, `
+SELECT `+incidentColumns+`
 FROM incidents
-WHERE id = $1
-`, id)
+WHERE external_id = $1
+`, externalID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inc, nil
+}
 
+const incidentColumns = `id, created_at, status, severity, description, summary, root_cause, resolved_at, service, auto_created, tags, external_id, occurrence_count, last_seen_at, acknowledged_by, acknowledged_at, summary_updated_at, snoozed_until, updated_at, version`
+
+func scanIncident(row pgx.Row) (Incident, error) {
 	var inc Incident
-	if err := row.Scan(
+	err := row.Scan(
 		&inc.ID,
 		&inc.CreatedAt,
 		&inc.Status,
@@ -177,38 +839,418 @@ WHERE id = $1
 		&inc.Summary,
 		&inc.RootCause,
 		&inc.ResolvedAt,
-	); err != nil {
+		&inc.Service,
+		&inc.AutoCreated,
+		&inc.Tags,
+		&inc.ExternalID,
+		&inc.OccurrenceCount,
+		&inc.LastSeenAt,
+		&inc.AcknowledgedBy,
+		&inc.AcknowledgedAt,
+		&inc.SummaryUpdatedAt,
+		&inc.SnoozedUntil,
+		&inc.UpdatedAt,
+		&inc.Version,
+	)
+	return inc, err
+}
+
+// IncidentSort names the timestamp incident lists are ordered by, newest
+// first.
+type IncidentSort string
+
+const (
+	SortByCreated IncidentSort = "created_at"
+	SortByUpdated IncidentSort = "updated_at"
+)
+
+// orderBy returns the ORDER BY clause for s, defaulting to creation time.
+func (s IncidentSort) orderBy() string {
+	if s == SortByUpdated {
+		return "updated_at DESC, id DESC"
+	}
+	return "created_at DESC, id DESC"
+}
+
+func (r *repository) ListIncidents(ctx context.Context, limit int, sort IncidentSort) ([]Incident, error) {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+ORDER BY `+sort.orderBy()+`
+LIMIT $1
+`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, inc)
+	}
+	return res, rows.Err()
+}
+
+// CountIncidents counts all incidents, or only a service's when service is
+// non-empty.
+func (r *repository) CountIncidents(ctx context.Context, service string) (int64, error) {
+	var n int64
+	err := r.incidentReader().QueryRow(ctx, `
+SELECT COUNT(*) FROM incidents WHERE $1 = '' OR service = $1
+`, service).Scan(&n)
+	return n, err
+}
+
+// ListIncidentsSince returns incidents created at or after since, newest first.
+func (r *repository) ListIncidentsSince(ctx context.Context, since time.Time) ([]Incident, error) {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE created_at >= $1
+ORDER BY created_at DESC
+`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, inc)
+	}
+	return res, rows.Err()
+}
+
+func (r *repository) ListServiceIncidents(ctx context.Context, service string, limit int, sort IncidentSort) ([]Incident, error) {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE service = $1
+ORDER BY `+sort.orderBy()+`
+LIMIT $2
+`, service, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, inc)
+	}
+	return res, rows.Err()
+}
+
+func (r *repository) ListUnresolvedIncidents(ctx context.Context, limit int) ([]Incident, error) {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE status <> 'resolved'
+ORDER BY created_at DESC
+LIMIT $1
+`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, inc)
+	}
+	return res, rows.Err()
+}
+
+// ListUnanalyzedIncidents returns incidents that have no summary yet, or a
+// blank one, most severe first and oldest first within a severity, for
+// analysis backfills.
+func (r *repository) ListUnanalyzedIncidents(ctx context.Context, limit int) ([]Incident, error) {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE summary IS NULL OR btrim(summary) = ''
+ORDER BY CASE severity
+    WHEN 'critical' THEN 4
+    WHEN 'high' THEN 3
+    WHEN 'medium' THEN 2
+    WHEN 'low' THEN 1
+    ELSE 0
+END DESC, created_at ASC, id ASC
+LIMIT $1
+`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, inc)
+	}
+	return res, rows.Err()
+}
+
+func (r *repository) GetIncident(ctx context.Context, id int64) (*Incident, error) {
+	inc, err := scanIncident(r.incidentReader().QueryRow(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE id = $1
+`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
 		return nil, err
 	}
 	return &inc, nil
 }
 
 func (r *repository) UpdateIncidentSummary(ctx context.Context, id int64, summary, rootCause string) error {
-	_, err := r.pool.Exec(ctx, `
+	return r.asActor(ctx, auth.Actor(ctx), func(q auditQuerier) error {
+		_, err := updateIncidentSummary(ctx, q, id, summary, rootCause)
+		return err
+	})
+}
+
+// SummaryImport is one incident's analysis to store.
+type SummaryImport struct {
+	IncidentID int64
+	Summary    string
+	RootCause  string
+}
+
+// ImportIncidentSummaries stores every analysis in one transaction, as
+// UpdateIncidentSummary would, and reports for each whether its incident
+// exists. Analyses for unknown incidents are skipped.
+func (r *repository) ImportIncidentSummaries(ctx context.Context, imports []SummaryImport) ([]bool, error) {
+	found := make([]bool, len(imports))
+	err := r.asActor(ctx, auth.Actor(ctx), func(q auditQuerier) error {
+		for i, im := range imports {
+			var err error
+			if found[i], err = updateIncidentSummary(ctx, q, im.IncidentID, im.Summary, im.RootCause); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func updateIncidentSummary(ctx context.Context, q auditQuerier, id int64, summary, rootCause string) (bool, error) {
+	tag, err := q.Exec(ctx, `
 UPDATE incidents
 SET summary = $2,
-    root_cause = $3
+    root_cause = $3,
+    summary_updated_at = NOW(),
+    updated_at = NOW(),
+    version = version + 1
 WHERE id = $1
 `, id, summary, rootCause)
-	return err
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
 }
 
-func (r *repository) UpdateIncidentStatus(ctx context.Context, id int64, status string) error {
-	var err error
+// UpdateIncidentStatus changes an incident's status if it is still at the
+// given version, returning the new version.
+func (r *repository) UpdateIncidentStatus(ctx context.Context, id int64, status string, version int64) (int64, error) {
 	if status == "resolved" {
-		_, err = r.pool.Exec(ctx, `
-UPDATE incidents
-SET status = $2,
-    resolved_at = NOW()
-WHERE id = $1
-`, id, status)
-	} else {
-		_, err = r.pool.Exec(ctx, `
+		return r.updateIncidentVersion(ctx, id, version, `status = $3, resolved_at = NOW()`, status)
+	}
+	return r.updateIncidentVersion(ctx, id, version, `status = $3`, status)
+}
+
+// AcknowledgeIncident marks an incident acknowledged, recording who did it
+// when by is non-empty, if it is still at the given version. It restarts the
+// reminder clock.
+func (r *repository) AcknowledgeIncident(ctx context.Context, id int64, by string, version int64) (int64, error) {
+	return r.updateIncidentVersion(ctx, id, version, `
+    status = 'acknowledged',
+    acknowledged_at = NOW(),
+    acknowledged_by = NULLIF($3, ''),
+    ack_reminded_at = NULL,
+    ack_escalated_at = NULL`, by)
+}
+
+// updateIncidentVersion applies set (whose parameters start at $3) to an
+// incident only while it is at version, and bumps the version. It returns
+// ErrStaleVersion when someone else wrote first.
+func (r *repository) updateIncidentVersion(ctx context.Context, id, version int64, set string, args ...any) (int64, error) {
+	var next int64
+	err := r.asActor(ctx, auth.Actor(ctx), func(q auditQuerier) error {
+		return q.QueryRow(ctx, `
 UPDATE incidents
-SET status = $2
-WHERE id = $1
-`, id, status)
+SET `+set+`,
+    updated_at = NOW(),
+    version = version + 1
+WHERE id = $1 AND version = $2
+RETURNING version
+`, append([]any{id, version}, args...)...).Scan(&next)
+	})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return next, err
+	}
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM incidents WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, ErrNotFound
+	}
+	return 0, ErrStaleVersion
+}
+
+// AutoResolveQuietIncidents resolves open auto-created incidents whose service
+// has logged no error-level entries since quietSince, recording an
+// auto_resolved event for each. It returns the resolved incident IDs.
+func (r *repository) AutoResolveQuietIncidents(ctx context.Context, quietSince time.Time) ([]int64, error) {
+	rows, err := r.pool.Query(ctx, `
+WITH resolved AS (
+    UPDATE incidents i
+    SET status = 'resolved',
+        resolved_at = NOW(),
+        updated_at = NOW(),
+        version = i.version + 1
+    WHERE i.status = 'open'
+      AND i.auto_created
+      AND i.service IS NOT NULL
+      AND i.created_at < $1
+      AND NOT EXISTS (
+          SELECT 1 FROM logs l
+          WHERE l.service = i.service
+            AND l.level = ANY($3)
+            AND l.timestamp >= $1
+      )
+    RETURNING i.id
+)
+INSERT INTO incident_events (incident_id, kind, message)
+SELECT id, 'auto_resolved', $2
+FROM resolved
+RETURNING incident_id
+`, quietSince, fmt.Sprintf("no error logs since %s", quietSince.UTC().Format(time.RFC3339)), ErrorLevels)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *repository) ListIncidentEvents(ctx context.Context, incidentID int64) ([]IncidentEvent, error) {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT id, incident_id, created_at, kind, message, actor
+FROM incident_events
+WHERE incident_id = $1
+ORDER BY created_at, id
+`, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []IncidentEvent
+	for rows.Next() {
+		var ev IncidentEvent
+		if err := rows.Scan(&ev.ID, &ev.IncidentID, &ev.CreatedAt, &ev.Kind, &ev.Message, &ev.Actor); err != nil {
+			return nil, err
+		}
+		res = append(res, ev)
+	}
+	return res, rows.Err()
+}
+
+func (r *repository) AddIncidentEvent(ctx context.Context, incidentID int64, kind, message, actor string) error {
+	_, err := r.pool.Exec(ctx, `
+INSERT INTO incident_events (incident_id, kind, message, actor)
+VALUES ($1, $2, $3, $4)
+`, incidentID, kind, message, actor)
+	if err != nil {
+		return err
+	}
+	r.noteIncidentWrite()
+	return nil
+}
+
+// ListRecentlyUpdatedIncidents returns the most recently changed incidents,
+// only those of the given severities when any are given.
+func (r *repository) ListRecentlyUpdatedIncidents(ctx context.Context, severities []string, limit int) ([]Incident, error) {
+	rows, err := r.incidentReader().Query(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE COALESCE(cardinality($1::text[]), 0) = 0 OR severity = ANY($1::text[])
+ORDER BY updated_at DESC, id DESC
+LIMIT $2
+`, severities, limit)
+	if err != nil {
+		return nil, err
 	}
-	return err
+	defer rows.Close()
+
+	var res []Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, inc)
+	}
+	return res, rows.Err()
 }
 
+// GetLogsByIDs returns the logs with the given IDs, oldest first. IDs with
+// no log are skipped.
+func (r *repository) GetLogsByIDs(ctx context.Context, ids []int64) ([]LogEntry, error) {
+	rows, err := r.reader().Query(ctx, `
+SELECT `+logColumns+`
+FROM logs
+WHERE id = ANY($1::bigint[])
+ORDER BY timestamp, id
+`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []LogEntry
+	for rows.Next() {
+		l, err := scanLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, l)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// Per-incident outcomes of RetagIncidents.
+const (
+	RetagUpdated   = "updated"
+	RetagUnchanged = "unchanged"
+	RetagNotFound  = "not_found"
+	RetagRejected  = "rejected"
+)
+
+type RetagResult struct {
+	ID      int64    `json:"id"`
+	Status  string   `json:"status"`
+	Tags    []string `json:"tags,omitempty"`
+	Version int64    `json:"version,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// RetagIncidents rewrites the tags of each incident with retag in one
+// transaction, recording a "tagged" event on those that change. An incident
+// retag refuses is reported and left as it was; the rest still commit.
+func (r *repository) RetagIncidents(ctx context.Context, ids []int64, actor string, retag func(tags []string) ([]string, error)) ([]RetagResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	if err := setAuditActor(ctx, tx, actor); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT id, tags, version
+FROM incidents
+WHERE id = ANY($1)
+ORDER BY id
+FOR UPDATE
+`, ids)
+	if err != nil {
+		return nil, err
+	}
+	type current struct {
+		tags    []string
+		version int64
+	}
+	found := make(map[int64]current, len(ids))
+	for rows.Next() {
+		var id int64
+		var cur current
+		if err := rows.Scan(&id, &cur.tags, &cur.version); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		found[id] = cur
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]RetagResult, 0, len(ids))
+	for _, id := range ids {
+		cur, ok := found[id]
+		if !ok {
+			res = append(res, RetagResult{ID: id, Status: RetagNotFound})
+			continue
+		}
+		tags, err := retag(slices.Clone(cur.tags))
+		if err != nil {
+			res = append(res, RetagResult{ID: id, Status: RetagRejected, Tags: cur.tags, Version: cur.version, Error: err.Error()})
+			continue
+		}
+		if tags == nil {
+			tags = []string{}
+		}
+		if slices.Equal(tags, cur.tags) {
+			res = append(res, RetagResult{ID: id, Status: RetagUnchanged, Tags: tags, Version: cur.version})
+			continue
+		}
+
+		message := "tags cleared"
+		if len(tags) > 0 {
+			message = "tags: " + strings.Join(tags, ", ")
+		}
+		var version int64
+		err = tx.QueryRow(ctx, `
+WITH tagged AS (
+    UPDATE incidents
+    SET tags = $2,
+        updated_at = NOW(),
+        version = version + 1
+    WHERE id = $1
+    RETURNING id, version
+), event AS (
+    INSERT INTO incident_events (incident_id, kind, message, actor)
+    SELECT id, 'tagged', $3, $4
+    FROM tagged
+)
+SELECT version FROM tagged
+`, id, tags, message, actor).Scan(&version)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, RetagResult{ID: id, Status: RetagUpdated, Tags: tags, Version: version})
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	r.noteIncidentWrite()
+	return res, nil
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type IncidentTemplate struct {
+	Name                string    `json:"name"`
+	CreatedAt           time.Time `json:"created_at"`
+	DefaultSeverity     string    `json:"default_severity"`
+	DescriptionTemplate string    `json:"description_template"`
+	Tags                []string  `json:"tags"`
+}
+
+func (r *repository) CreateIncidentTemplate(ctx context.Context, t *IncidentTemplate) error {
+	err := r.pool.QueryRow(ctx, `
+INSERT INTO incident_templates (name, default_severity, description_template, tags)
+VALUES ($1, $2, $3, COALESCE($4::text[], '{}'))
+RETURNING created_at
+`, t.Name, t.DefaultSeverity, t.DescriptionTemplate, t.Tags).Scan(&t.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *repository) ListIncidentTemplates(ctx context.Context) ([]IncidentTemplate, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT name, created_at, default_severity, description_template, tags
+FROM incident_templates
+ORDER BY name
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []IncidentTemplate
+	for rows.Next() {
+		var t IncidentTemplate
+		if err := rows.Scan(&t.Name, &t.CreatedAt, &t.DefaultSeverity, &t.DescriptionTemplate, &t.Tags); err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, rows.Err()
+}
+
+func (r *repository) GetIncidentTemplate(ctx context.Context, name string) (*IncidentTemplate, error) {
+	var t IncidentTemplate
+	err := r.pool.QueryRow(ctx, `
+SELECT name, created_at, default_severity, description_template, tags
+FROM incident_templates
+WHERE name = $1
+`, name).Scan(&t.Name, &t.CreatedAt, &t.DefaultSeverity, &t.DescriptionTemplate, &t.Tags)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *repository) DeleteIncidentTemplate(ctx context.Context, name string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM incident_templates WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// IsUnavailable reports whether err means the database could not be reached
+// or is refusing work, as opposed to rejecting the data itself, such that
+// nothing was stored. A *PartialInsertError counts when its failed chunks do.
+// Deadlines and connections lost mid-statement don't count: the write may
+// have committed, so storing it again elsewhere would duplicate it.
+func IsUnavailable(err error) bool {
+	var partial *PartialInsertError
+	if errors.As(err, &partial) {
+		return len(partial.Failed) > 0 && IsUnavailable(partial.Failed[0].Err)
+	}
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Connection exceptions, too many connections and the server
+		// shutting down.
+		return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "53300" || strings.HasPrefix(pgErr.Code, "57P")
+	}
+	// Failing to connect or to acquire a pooled connection, or any other
+	// error before the statement was sent.
+	var connectErr *pgconn.ConnectError
+	return errors.As(err, &connectErr) || pgconn.SafeToRetry(err)
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"too many connections", &pgconn.PgError{Code: "53300"}, true},
+		{"admin shutdown", fmt.Errorf("insert: %w", &pgconn.PgError{Code: "57P01"}), true},
+		{"constraint violation", &pgconn.PgError{Code: "23505"}, false},
+		{"connect failure", &pgconn.ConnectError{}, true},
+		{"deadline", fmt.Errorf("insert: %w", context.DeadlineExceeded), false},
+		{"canceled", context.Canceled, false},
+		{"connection lost mid-statement", io.ErrUnexpectedEOF, false},
+		{"partial, unavailable chunk", &PartialInsertError{Failed: []LogChunkError{{Err: &pgconn.ConnectError{}}}}, true},
+		{"partial, timed out chunk", &PartialInsertError{Failed: []LogChunkError{{Err: context.DeadlineExceeded}}}, false},
+	}
+	for _, tt := range tests {
+		if got := IsUnavailable(tt.err); got != tt.want {
+			t.Errorf("%s: IsUnavailable(%v) = %t, want %t", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrBusy means another maintenance run holds the lock.
+var ErrBusy = errors.New("maintenance already running")
+
+// VacuumTables are the tables VacuumAnalyze accepts.
+var VacuumTables = map[string]bool{"logs": true, "incidents": true}
+
+// VacuumAnalyze runs VACUUM (ANALYZE) on each table in turn. A session
+// advisory lock keeps runs from overlapping, across instances too; it
+// returns ErrBusy instead of waiting when one is already in progress.
+func (r *repository) VacuumAnalyze(ctx context.Context, tables []string) error {
+	for _, table := range tables {
+		if !VacuumTables[table] {
+			return fmt.Errorf("table %q cannot be vacuumed", table)
+		}
+	}
+
+	// VACUUM can't run in a transaction and the lock is per session, so
+	// everything happens on one dedicated connection.
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext('vacuum_analyze'))`).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		return ErrBusy
+	}
+	// If ctx is canceled mid-VACUUM pgx closes the connection, which drops
+	// the lock with it.
+	defer conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock(hashtext('vacuum_analyze'))`)
+
+	for _, table := range tables {
+		if _, err := conn.Exec(ctx, `VACUUM (ANALYZE) `+table); err != nil {
+			return fmt.Errorf("vacuum %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type IncidentComment struct {
+	ID         int64     `json:"id"`
+	IncidentID int64     `json:"incident_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Author     *string   `json:"author"`
+	Body       string    `json:"body"`
+}
+
+func (r *repository) AddIncidentWatcher(ctx context.Context, incidentID int64, subscriber string) error {
+	_, err := r.pool.Exec(ctx, `
+INSERT INTO incident_watchers (incident_id, subscriber)
+VALUES ($1, $2)
+ON CONFLICT (incident_id, subscriber) DO NOTHING
+`, incidentID, subscriber)
+	return err
+}
+
+func (r *repository) RemoveIncidentWatcher(ctx context.Context, incidentID int64, subscriber string) error {
+	tag, err := r.pool.Exec(ctx, `
+DELETE FROM incident_watchers
+WHERE incident_id = $1 AND subscriber = $2
+`, incidentID, subscriber)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) ListIncidentWatchers(ctx context.Context, incidentID int64) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT subscriber
+FROM incident_watchers
+WHERE incident_id = $1
+ORDER BY created_at, subscriber
+`, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+func (r *repository) CreateIncidentComment(ctx context.Context, comment *IncidentComment) error {
+	return r.pool.QueryRow(ctx, `
+INSERT INTO incident_comments (incident_id, author, body)
+VALUES ($1, $2, $3)
+RETURNING id, created_at
+`, comment.IncidentID, comment.Author, comment.Body).Scan(&comment.ID, &comment.CreatedAt)
+}
+
+func (r *repository) ListIncidentComments(ctx context.Context, incidentID int64) ([]IncidentComment, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT id, incident_id, created_at, author, body
+FROM incident_comments
+WHERE incident_id = $1
+ORDER BY created_at, id
+`, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []IncidentComment
+	for rows.Next() {
+		var cm IncidentComment
+		if err := rows.Scan(&cm.ID, &cm.IncidentID, &cm.CreatedAt, &cm.Author, &cm.Body); err != nil {
+			return nil, err
+		}
+		res = append(res, cm)
+	}
+	return res, rows.Err()
+}
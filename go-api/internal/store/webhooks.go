@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Webhook delivery statuses. A pending delivery is retried at
+// NextAttemptAt; a dead one has used up its attempts and waits for a manual
+// replay.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery records one notification sent, or still to be sent, to one
+// notifier.
+type WebhookDelivery struct {
+	ID            int64           `json:"id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Notifier      string          `json:"notifier"`
+	IncidentID    int64           `json:"incident_id"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+}
+
+const webhookDeliveryColumns = `id, created_at, notifier, incident_id, payload, status, attempts, last_error, next_attempt_at, delivered_at`
+
+func scanWebhookDelivery(row pgx.Row) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	err := row.Scan(&d.ID, &d.CreatedAt, &d.Notifier, &d.IncidentID, &d.Payload, &d.Status, &d.Attempts, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt)
+	return d, err
+}
+
+func (r *repository) CreateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error {
+	return r.pool.QueryRow(ctx, `
+INSERT INTO webhook_deliveries (notifier, incident_id, payload, status, attempts, last_error, next_attempt_at, delivered_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, created_at
+`, d.Notifier, d.IncidentID, d.Payload, d.Status, d.Attempts, d.LastError, d.NextAttemptAt, d.DeliveredAt).Scan(&d.ID, &d.CreatedAt)
+}
+
+// UpdateWebhookDelivery saves the outcome of another delivery attempt.
+func (r *repository) UpdateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error {
+	_, err := r.pool.Exec(ctx, `
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = $3,
+    last_error = $4,
+    next_attempt_at = $5,
+    delivered_at = $6
+WHERE id = $1
+`, d.ID, d.Status, d.Attempts, d.LastError, d.NextAttemptAt, d.DeliveredAt)
+	return err
+}
+
+// ClaimDueWebhookDeliveries returns pending deliveries whose next attempt is
+// due, oldest first, pushing their next attempt out by lease so another
+// instance polling at the same time skips them.
+func (r *repository) ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	rows, err := r.pool.Query(ctx, `
+UPDATE webhook_deliveries
+SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+WHERE id IN (
+    SELECT id
+    FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING `+webhookDeliveryColumns+`
+`, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+// ListDeadWebhookDeliveries returns deliveries that used up their attempts,
+// oldest first.
+func (r *repository) ListDeadWebhookDeliveries(ctx context.Context, limit int) ([]WebhookDelivery, error) {
+	rows, err := r.pool.Query(ctx, `
+SELECT `+webhookDeliveryColumns+`
+FROM webhook_deliveries
+WHERE status = 'dead'
+ORDER BY id
+LIMIT $1
+`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
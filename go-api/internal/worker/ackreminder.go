@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"Incident_Monitoring_Project/internal/notify"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// AckReminder nudges whoever acknowledged an incident when it stays
+// unresolved for remindAfter, then escalates to the escalateTo notifiers (or
+// the incident's severity route when none are named) after escalateAfter.
+// Both are measured from the acknowledgement and sent once per
+// acknowledgement; a snoozed incident is passed over until its snooze ends.
+type AckReminder struct {
+	repo          store.Repository
+	notifier      *notify.Dispatcher
+	remindAfter   time.Duration
+	escalateAfter time.Duration
+	escalateTo    []string
+	interval      time.Duration
+}
+
+func NewAckReminder(repo store.Repository, notifier *notify.Dispatcher, remindAfter, escalateAfter time.Duration, escalateTo []string, interval time.Duration) *AckReminder {
+	return &AckReminder{
+		repo:          repo,
+		notifier:      notifier,
+		remindAfter:   remindAfter,
+		escalateAfter: escalateAfter,
+		escalateTo:    escalateTo,
+		interval:      interval,
+	}
+}
+
+func (w *AckReminder) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !paused(ctx) {
+				w.runOnce(context.WithoutCancel(ctx))
+			}
+		}
+	}
+}
+
+func (w *AckReminder) runOnce(ctx context.Context) {
+	now := time.Now()
+	var escalateBefore time.Time
+	if w.escalateAfter > 0 {
+		escalateBefore = now.Add(-w.escalateAfter)
+	}
+	incidents, err := w.repo.ListAckReminderDue(ctx, now.Add(-w.remindAfter), escalateBefore, slaScanLimit)
+	if err != nil {
+		log.Printf("ack-reminder: %v", err)
+		return
+	}
+
+	for i := range incidents {
+		inc := &incidents[i]
+		age := now.Sub(*inc.AcknowledgedAt)
+		if age >= w.remindAfter {
+			w.remind(ctx, inc, age)
+		}
+		if w.escalateAfter > 0 && age >= w.escalateAfter {
+			w.escalate(ctx, inc, age)
+		}
+	}
+}
+
+func (w *AckReminder) remind(ctx context.Context, inc *store.Incident, age time.Duration) {
+	text := fmt.Sprintf("Acknowledged %s ago and still unresolved", age.Round(time.Minute))
+	if !w.mark(ctx, inc, "remind", text) {
+		return
+	}
+	msg := notify.Message{
+		IncidentID: inc.ID,
+		Title:      fmt.Sprintf("Reminder: incident #%d is still open", inc.ID),
+		Text:       text,
+	}
+	if inc.AcknowledgedBy != nil {
+		msg.Recipient = *inc.AcknowledgedBy
+	} else {
+		msg.Severity = inc.Severity
+	}
+	w.notifier.Dispatch(ctx, msg)
+}
+
+func (w *AckReminder) escalate(ctx context.Context, inc *store.Incident, age time.Duration) {
+	text := fmt.Sprintf("Acknowledged %s ago", age.Round(time.Minute))
+	if inc.AcknowledgedBy != nil {
+		text += " by " + *inc.AcknowledgedBy
+	}
+	text += " and still unresolved"
+	if !w.mark(ctx, inc, "escalate", text) {
+		return
+	}
+	msg := notify.Message{
+		IncidentID: inc.ID,
+		Severity:   inc.Severity,
+		Title:      fmt.Sprintf("Escalation: %s incident #%d is stuck", inc.Severity, inc.ID),
+		Text:       text,
+		Urgent:     true,
+	}
+	if len(w.escalateTo) > 0 {
+		w.notifier.DispatchTo(ctx, w.escalateTo, msg)
+	} else {
+		w.notifier.Dispatch(ctx, msg)
+	}
+}
+
+func (w *AckReminder) mark(ctx context.Context, inc *store.Incident, stage, text string) bool {
+	marked, err := w.repo.MarkAckReminder(ctx, inc.ID, stage, text)
+	if err != nil {
+		log.Printf("ack-reminder: incident %d: %v", inc.ID, err)
+		return false
+	}
+	if marked {
+		log.Printf("ack-reminder: incident %d %s: %s", inc.ID, stage, text)
+	}
+	return marked
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"Incident_Monitoring_Project/internal/notify"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// AutoResolver periodically resolves auto-created incidents whose service has
+// stopped producing error logs for the configured quiet window.
+type AutoResolver struct {
+	repo     store.Repository
+	notifier *notify.Dispatcher
+	quiet    time.Duration
+	interval time.Duration
+}
+
+func NewAutoResolver(repo store.Repository, notifier *notify.Dispatcher, quiet, interval time.Duration) *AutoResolver {
+	return &AutoResolver{repo: repo, notifier: notifier, quiet: quiet, interval: interval}
+}
+
+func (w *AutoResolver) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !paused(ctx) {
+				w.runOnce(context.WithoutCancel(ctx))
+			}
+		}
+	}
+}
+
+func (w *AutoResolver) runOnce(ctx context.Context) {
+	ids, err := w.repo.AutoResolveQuietIncidents(ctx, time.Now().Add(-w.quiet))
+	if err != nil {
+		log.Printf("auto-resolve: %v", err)
+		return
+	}
+	if len(ids) > 0 {
+		log.Printf("auto-resolve: resolved incidents %v after %s without errors", ids, w.quiet)
+	}
+	for _, id := range ids {
+		notify.NotifyWatchers(ctx, w.repo, w.notifier, notify.Message{
+			IncidentID: id,
+			Title:      fmt.Sprintf("Incident #%d was auto-resolved", id),
+			Text:       fmt.Sprintf("No error logs for %s.", w.quiet),
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"Incident_Monitoring_Project/internal/auth"
+	"Incident_Monitoring_Project/internal/detection"
+	"Incident_Monitoring_Project/internal/notify"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// Detector turns error bursts into auto-created incidents. Bursts for a
+// service already covered by an open auto-created incident bump that
+// incident's occurrence count instead, and services inside a maintenance
+// window are skipped.
+type Detector struct {
+	repo     store.Repository
+	detector *detection.Detector
+	notifier *notify.Dispatcher
+	interval time.Duration
+}
+
+func NewDetector(repo store.Repository, detector *detection.Detector, notifier *notify.Dispatcher, interval time.Duration) *Detector {
+	return &Detector{repo: repo, detector: detector, notifier: notifier, interval: interval}
+}
+
+func (w *Detector) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !paused(ctx) {
+				w.runOnce(context.WithoutCancel(ctx))
+			}
+		}
+	}
+}
+
+func (w *Detector) runOnce(ctx context.Context) {
+	now := time.Now()
+	candidates, err := w.detector.Evaluate(ctx, now)
+	if err != nil {
+		log.Printf("detection: %v", err)
+		return
+	}
+
+	for _, c := range candidates {
+		if err := w.apply(ctx, c, now); err != nil {
+			log.Printf("detection: service %s: %v", c.Service, err)
+		}
+	}
+
+	ids, err := w.repo.TagMaintenanceIncidents(ctx)
+	if err != nil {
+		log.Printf("detection: tagging maintenance incidents: %v", err)
+	} else if len(ids) > 0 {
+		log.Printf("detection: tagged incidents %v created during maintenance", ids)
+	}
+}
+
+func (w *Detector) apply(ctx context.Context, c detection.Candidate, now time.Time) error {
+	inMaintenance, err := w.repo.InMaintenance(ctx, c.Service, now)
+	if err != nil {
+		return err
+	}
+	if inMaintenance {
+		log.Printf("detection: suppressed incident for %s (maintenance window)", c.Service)
+		return nil
+	}
+
+	existing, err := w.repo.FindOpenAutoIncident(ctx, c.Service)
+	if err == nil {
+		return w.repo.RecordIncidentOccurrence(ctx, existing.ID, c.LastSeen)
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	service := c.Service
+	lastSeen := c.LastSeen
+	inc := &store.Incident{
+		Status:      "open",
+		Severity:    c.Severity,
+		Description: c.Description,
+		Service:     &service,
+		AutoCreated: true,
+		LastSeenAt:  &lastSeen,
+	}
+	if _, err := w.repo.CreateIncident(ctx, inc); err != nil {
+		return err
+	}
+	log.Printf("detection: created incident %d for %s", inc.ID, c.Service)
+	w.notifier.Dispatch(ctx, notify.IncidentOpened(inc))
+	message := c.Description
+	if r := c.SeverityRule; r != nil {
+		message += fmt.Sprintf(" (severity rule: %q -> %s)", r.Keyword, r.Severity)
+	}
+	return w.repo.AddIncidentEvent(ctx, inc.ID, "detected", message, auth.SystemActor)
+}
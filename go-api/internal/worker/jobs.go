@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// JobJanitor periodically marks jobs whose owning instance has stopped
+// heartbeating as interrupted, and deletes finished jobs past the
+// retention period.
+type JobJanitor struct {
+	repo      store.Repository
+	lease     time.Duration
+	retention time.Duration
+}
+
+// NewJobJanitor checks every lease. A retention of zero keeps finished jobs
+// forever.
+func NewJobJanitor(repo store.Repository, lease, retention time.Duration) *JobJanitor {
+	return &JobJanitor{repo: repo, lease: lease, retention: retention}
+}
+
+func (w *JobJanitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.lease)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !paused(ctx) {
+				w.runOnce(context.WithoutCancel(ctx))
+			}
+		}
+	}
+}
+
+func (w *JobJanitor) runOnce(ctx context.Context) {
+	n, err := w.repo.InterruptJobs(ctx, time.Now().Add(-w.lease))
+	if err != nil {
+		log.Printf("job-janitor: %v", err)
+	}
+	if n > 0 {
+		log.Printf("job-janitor: marked %d jobs with no heartbeat for %s interrupted", n, w.lease)
+	}
+	if w.retention <= 0 {
+		return
+	}
+	n, err = w.repo.DeleteFinishedJobs(ctx, time.Now().Add(-w.retention))
+	if err != nil {
+		log.Printf("job-janitor: %v", err)
+	}
+	if n > 0 {
+		log.Printf("job-janitor: deleted %d jobs finished more than %s ago", n, w.retention)
+	}
+}
@@ -0,0 +1,143 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// drainLogInterval is how often Shutdown reports workers still finishing.
+const drainLogInterval = 5 * time.Second
+
+var ErrUnknownWorker = errors.New("unknown worker")
+
+type pausedKey struct{}
+
+// paused reports whether the worker running under ctx has been paused with
+// Manager.SetPaused. Workers check it before each iteration.
+func paused(ctx context.Context) bool {
+	flag, _ := ctx.Value(pausedKey{}).(*atomic.Bool)
+	return flag != nil && flag.Load()
+}
+
+// State is a worker's name, whether it is paused, and how many instances of
+// it are running.
+type State struct {
+	Name    string `json:"name"`
+	Paused  bool   `json:"paused"`
+	Running int    `json:"running"`
+}
+
+// Manager runs background workers under one context so they can be stopped
+// together. Workers stop starting new iterations once the context is
+// canceled; iterations already underway run to completion.
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]int
+	paused  map[string]*atomic.Bool
+}
+
+func NewManager(parent context.Context) *Manager {
+	ctx, cancel := context.WithCancel(parent)
+	return &Manager{ctx: ctx, cancel: cancel, running: make(map[string]int), paused: make(map[string]*atomic.Bool)}
+}
+
+// Go starts run in its own goroutine with the manager's context. Workers
+// started under the same name share one pause flag.
+func (m *Manager) Go(name string, run func(ctx context.Context)) {
+	m.mu.Lock()
+	m.running[name]++
+	flag, ok := m.paused[name]
+	if !ok {
+		flag = new(atomic.Bool)
+		m.paused[name] = flag
+	}
+	m.mu.Unlock()
+	ctx := context.WithValue(m.ctx, pausedKey{}, flag)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			if m.running[name]--; m.running[name] == 0 {
+				delete(m.running, name)
+			}
+			m.mu.Unlock()
+		}()
+		run(ctx)
+	}()
+}
+
+// SetPaused pauses or resumes the named worker from its next iteration on;
+// one already underway finishes.
+func (m *Manager) SetPaused(name string, paused bool) error {
+	m.mu.Lock()
+	flag, ok := m.paused[name]
+	m.mu.Unlock()
+	if !ok {
+		return ErrUnknownWorker
+	}
+	flag.Store(paused)
+	return nil
+}
+
+// States lists every worker started so far, by name.
+func (m *Manager) States() []State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	states := make([]State, 0, len(m.paused))
+	for name, flag := range m.paused {
+		states = append(states, State{Name: name, Paused: flag.Load(), Running: m.running[name]})
+	}
+	slices.SortFunc(states, func(a, b State) int { return strings.Compare(a.Name, b.Name) })
+	return states
+}
+
+// Shutdown cancels every worker and waits for them to return, or for ctx to
+// end. It reports whether all workers finished, logging the ones still
+// draining while it waits and any left behind when it gives up.
+func (m *Manager) Shutdown(ctx context.Context) bool {
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(drainLogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			log.Printf("workers: all stopped")
+			return true
+		case <-ticker.C:
+			log.Printf("workers: still draining %v", m.draining())
+		case <-ctx.Done():
+			log.Printf("workers: gave up waiting for %v: %v", m.draining(), ctx.Err())
+			return false
+		}
+	}
+}
+
+func (m *Manager) draining() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.running))
+	for name := range m.running {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// LogPartitioner keeps the partitioned logs table ahead of time, creating
+// the next few daily or monthly partitions, and drops partitions that fall
+// entirely outside the retention window.
+type LogPartitioner struct {
+	repo      store.Repository
+	period    string
+	ahead     int
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewLogPartitioner(repo store.Repository, period string, ahead int, retention, interval time.Duration) *LogPartitioner {
+	return &LogPartitioner{repo: repo, period: period, ahead: ahead, retention: retention, interval: interval}
+}
+
+func (w *LogPartitioner) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Partitions for today must exist before logs arrive, so don't wait for
+	// the first tick.
+	w.runOnce(context.WithoutCancel(ctx))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !paused(ctx) {
+				w.runOnce(context.WithoutCancel(ctx))
+			}
+		}
+	}
+}
+
+func (w *LogPartitioner) runOnce(ctx context.Context) {
+	created, err := w.repo.EnsureLogPartitions(ctx, w.period, w.ahead)
+	if len(created) > 0 {
+		log.Printf("log-partitions: ensured %v", created)
+	}
+	if err != nil {
+		log.Printf("log-partitions: %v", err)
+	}
+	if w.retention <= 0 {
+		return
+	}
+	dropped, purged, err := w.repo.DropExpiredLogPartitions(ctx, time.Now().Add(-w.retention))
+	if len(dropped) > 0 {
+		log.Printf("log-partitions: dropped %v past the %s retention", dropped, w.retention)
+	}
+	if purged > 0 {
+		log.Printf("log-partitions: deleted %d logs_default rows past the %s retention", purged, w.retention)
+	}
+	if err != nil {
+		log.Printf("log-partitions: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"Incident_Monitoring_Project/internal/metrics"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// PoolMetrics copies the database pool's stats into gauges on an interval.
+type PoolMetrics struct {
+	repo     store.Repository
+	interval time.Duration
+
+	acquired, idle, total, max, waitCount, waitSeconds metrics.Gauge
+}
+
+func NewPoolMetrics(repo store.Repository, reg *metrics.Registry, interval time.Duration) *PoolMetrics {
+	return &PoolMetrics{
+		repo:        repo,
+		interval:    interval,
+		acquired:    reg.Gauge("db_pool_acquired_conns", "Connections currently checked out of the pool."),
+		idle:        reg.Gauge("db_pool_idle_conns", "Idle connections in the pool."),
+		total:       reg.Gauge("db_pool_total_conns", "Open connections in the pool."),
+		max:         reg.Gauge("db_pool_max_conns", "Largest number of connections the pool will open."),
+		waitCount:   reg.Gauge("db_pool_wait_count", "Acquires that waited for a free connection since startup."),
+		waitSeconds: reg.Gauge("db_pool_wait_duration_seconds", "Time spent waiting for a free connection since startup."),
+	}
+}
+
+func (w *PoolMetrics) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.runOnce()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !paused(ctx) {
+				w.runOnce()
+			}
+		}
+	}
+}
+
+func (w *PoolMetrics) runOnce() {
+	s := w.repo.PoolStats()
+	w.acquired.Set(float64(s.Acquired))
+	w.idle.Set(float64(s.Idle))
+	w.total.Set(float64(s.Total))
+	w.max.Set(float64(s.Max))
+	w.waitCount.Set(float64(s.WaitCount))
+	w.waitSeconds.Set(s.WaitDuration.Seconds())
+}
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"Incident_Monitoring_Project/internal/notify"
+	"Incident_Monitoring_Project/internal/sla"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const slaScanLimit = 500
+
+// SLAMonitor periodically flags unresolved incidents that have breached their
+// acknowledge or resolve SLA, recording an event and notifying once per
+// breach.
+type SLAMonitor struct {
+	repo     store.Repository
+	notifier *notify.Dispatcher
+	targets  sla.Targets
+	interval time.Duration
+}
+
+func NewSLAMonitor(repo store.Repository, notifier *notify.Dispatcher, targets sla.Targets, interval time.Duration) *SLAMonitor {
+	return &SLAMonitor{repo: repo, notifier: notifier, targets: targets, interval: interval}
+}
+
+func (w *SLAMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !paused(ctx) {
+				w.runOnce(context.WithoutCancel(ctx))
+			}
+		}
+	}
+}
+
+func (w *SLAMonitor) runOnce(ctx context.Context) {
+	incidents, err := w.repo.ListUnresolvedIncidents(ctx, slaScanLimit)
+	if err != nil {
+		log.Printf("sla: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range incidents {
+		inc := &incidents[i]
+		st := w.targets.Evaluate(inc, now)
+		if st == nil {
+			continue
+		}
+		if st.AckBreached {
+			w.flag(ctx, inc, "ack", *st.AckDueAt)
+		}
+		if st.ResolveBreached {
+			w.flag(ctx, inc, "resolve", *st.ResolveDueAt)
+		}
+	}
+}
+
+func (w *SLAMonitor) flag(ctx context.Context, inc *store.Incident, kind string, due time.Time) {
+	msg := fmt.Sprintf("%s SLA breached (due %s)", kind, due.UTC().Format(time.RFC3339))
+	flagged, err := w.repo.FlagSLABreach(ctx, inc.ID, kind, msg)
+	if err != nil {
+		log.Printf("sla: incident %d: %v", inc.ID, err)
+		return
+	}
+	if !flagged {
+		return
+	}
+	log.Printf("sla: incident %d %s", inc.ID, msg)
+	w.notifier.Dispatch(ctx, notify.Message{
+		IncidentID: inc.ID,
+		Severity:   inc.Severity,
+		Title:      fmt.Sprintf("Incident #%d breached its %s SLA", inc.ID, kind),
+		Text:       msg,
+		Urgent:     true,
+	})
+}
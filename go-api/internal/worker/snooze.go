@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"Incident_Monitoring_Project/internal/notify"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// Unsnoozer periodically clears snoozes that have run out, so the incidents
+// return to the on-call queue and reminders.
+type Unsnoozer struct {
+	repo     store.Repository
+	notifier *notify.Dispatcher
+	interval time.Duration
+}
+
+func NewUnsnoozer(repo store.Repository, notifier *notify.Dispatcher, interval time.Duration) *Unsnoozer {
+	return &Unsnoozer{repo: repo, notifier: notifier, interval: interval}
+}
+
+func (w *Unsnoozer) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !paused(ctx) {
+				w.runOnce(context.WithoutCancel(ctx))
+			}
+		}
+	}
+}
+
+func (w *Unsnoozer) runOnce(ctx context.Context) {
+	ids, err := w.repo.ExpireSnoozes(ctx)
+	if err != nil {
+		log.Printf("unsnooze: %v", err)
+		return
+	}
+	for _, id := range ids {
+		notify.NotifyWatchers(ctx, w.repo, w.notifier, notify.Message{
+			IncidentID: id,
+			Title:      fmt.Sprintf("Incident #%d is no longer snoozed", id),
+			Text:       "The snooze ran out.",
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"Incident_Monitoring_Project/internal/spool"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+// SpoolReplayer periodically inserts log batches that were spooled to disk
+// while the database was unreachable.
+type SpoolReplayer struct {
+	spool    *spool.Spool
+	repo     store.Repository
+	interval time.Duration
+}
+
+func NewSpoolReplayer(s *spool.Spool, repo store.Repository, interval time.Duration) *SpoolReplayer {
+	return &SpoolReplayer{spool: s, repo: repo, interval: interval}
+}
+
+func (w *SpoolReplayer) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !paused(ctx) {
+				w.runOnce(context.WithoutCancel(ctx))
+			}
+		}
+	}
+}
+
+func (w *SpoolReplayer) runOnce(ctx context.Context) {
+	n, quarantined, err := w.spool.Replay(ctx, w.insert)
+	if n > 0 {
+		log.Printf("spool: replayed %d batches", n)
+	}
+	if quarantined > 0 {
+		log.Printf("spool: moved %d corrupt batches to the quarantine file", quarantined)
+	}
+	if err != nil {
+		log.Printf("spool: %v", err)
+	}
+}
+
+// insert stores one spooled batch. Logs the database rejects outright are
+// dropped with a log line, since replaying them again would not help.
+func (w *SpoolReplayer) insert(ctx context.Context, logs []store.LogEntry) error {
+	_, err := w.repo.InsertLogs(ctx, logs)
+	var partial *store.PartialInsertError
+	if errors.As(err, &partial) && len(partial.Failed) == 0 {
+		log.Printf("spool: dropped %d rejected logs: %v", len(partial.Rows), err)
+		return nil
+	}
+	return err
+}
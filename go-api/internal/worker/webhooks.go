@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"Incident_Monitoring_Project/internal/notify"
+	"Incident_Monitoring_Project/internal/store"
+)
+
+const (
+	webhookRetryBatch = 100
+	// webhookRetryLease keeps a claimed delivery from being picked up again
+	// while this worker is still attempting it.
+	webhookRetryLease = 5 * time.Minute
+)
+
+// WebhookRetrier periodically redelivers notifications whose earlier
+// attempts failed and whose backoff has elapsed.
+type WebhookRetrier struct {
+	repo     store.Repository
+	notifier *notify.Dispatcher
+	interval time.Duration
+}
+
+func NewWebhookRetrier(repo store.Repository, notifier *notify.Dispatcher, interval time.Duration) *WebhookRetrier {
+	return &WebhookRetrier{repo: repo, notifier: notifier, interval: interval}
+}
+
+func (w *WebhookRetrier) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !paused(ctx) {
+				w.runOnce(context.WithoutCancel(ctx))
+			}
+		}
+	}
+}
+
+func (w *WebhookRetrier) runOnce(ctx context.Context) {
+	due, err := w.repo.ClaimDueWebhookDeliveries(ctx, webhookRetryBatch, webhookRetryLease)
+	if err != nil {
+		log.Printf("webhook retry: %v", err)
+		return
+	}
+	for i := range due {
+		del := &due[i]
+		if err := w.notifier.Redeliver(ctx, del); err != nil {
+			log.Printf("webhook retry: delivery %d to %s (attempt %d, now %s): %v", del.ID, del.Notifier, del.Attempts, del.Status, err)
+		}
+	}
+}
@@ -230,14 +230,15 @@ async def detect_anomalies_endpoint():
             
             result = conn.execute(
                 text("""
-                    INSERT INTO incidents (status, severity, description)
-                    VALUES (:status, :severity, :description)
+                    INSERT INTO incidents (status, severity, description, service, auto_created)
+                    VALUES (:status, :severity, :description, :service, TRUE)
                     RETURNING id
                 """),
                 {
                     "status": "open",
                     "severity": severity,
                     "description": description,
+                    "service": anomaly.get("service"),
                 },
             )
             incident_id = result.scalar()
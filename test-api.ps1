@@ -19,7 +19,8 @@ function Invoke-ApiCall {
         [string]$Uri,
         [string]$Method = "GET",
         [object]$Body = $null,
-        [string]$Description = ""
+        [string]$Description = "",
+        [hashtable]$Headers = @{}
     )
 
     Write-Host "Testing: $Description" -ForegroundColor Yellow
@@ -31,6 +32,7 @@ function Invoke-ApiCall {
             Method = $Method
             ContentType = "application/json"
             UseBasicParsing = $true
+            Headers = $Headers
         }
 
         if ($Body) {
@@ -182,10 +184,12 @@ if ($incidentsAfter -and $incidentsAfter.Count -gt 0) {
 
 # Test 8: Test Incident Resolution
 if ($incidentsAfter -and $incidentsAfter.Count -gt 0) {
-    $lastIncidentId = $incidentsAfter[$incidentsAfter.Count - 1].id
+    $lastIncident = $incidentsAfter[$incidentsAfter.Count - 1]
+    $lastIncidentId = $lastIncident.id
     Write-Host "--- Step 8: Resolve Incident #$lastIncidentId ---" -ForegroundColor Magenta
     $resolveBody = @{ status = "resolved" }
-    $resolveResult = Invoke-ApiCall -Uri "$GO_API/api/incidents/$lastIncidentId" -Method PATCH -Body $resolveBody -Description "Resolve an Incident"
+    $resolveHeaders = @{ "If-Match" = "$($lastIncident.version)" }
+    $resolveResult = Invoke-ApiCall -Uri "$GO_API/api/incidents/$lastIncidentId" -Method PATCH -Body $resolveBody -Headers $resolveHeaders -Description "Resolve an Incident"
     Start-Sleep -Seconds 1
 }
 